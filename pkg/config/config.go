@@ -2,10 +2,42 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
 	OpenAI *OpenAIConfig
+
+	// QueryTimeout is the fallback timeout for SQL statements (0 = unlimited)
+	QueryTimeout time.Duration
+
+	// ConnectTimeout bounds opening SQL connections unless the connection sets its own
+	ConnectTimeout time.Duration
+
+	// SlowQueryThreshold logs SQL statements running longer than this (0 = disabled)
+	SlowQueryThreshold time.Duration
+
+	// MaxBodySize limits JSON request bodies, MaxUploadSize limits object uploads (in bytes)
+	MaxBodySize   int64
+	MaxUploadSize int64
+
+	// MultipartMemory is the size up to which form uploads are kept in memory,
+	// larger files are spooled to temporary files (in bytes)
+	MultipartMemory int64
+
+	// MaxQueryLength limits the size of SQL queries in bytes, MaxStatements the
+	// number of statements in a script (0 = unlimited)
+	MaxQueryLength int
+	MaxStatements  int
+
+	// MaxTransfers limits concurrent storage uploads and downloads (0 = unlimited)
+	MaxTransfers int
+
+	// ListPageSize is the object listing page size used when a request omits it,
+	// MaxListPageSize caps the page size a request may ask for (0 = provider default)
+	ListPageSize    int
+	MaxListPageSize int
 }
 
 type OpenAIConfig struct {
@@ -18,10 +50,34 @@ func New() (*Config, error) {
 	cfg := &Config{}
 
 	applyOpenAIConfig(cfg)
+	applyServerConfig(cfg)
 
 	return cfg, nil
 }
 
+func applyServerConfig(cfg *Config) {
+	cfg.QueryTimeout = envSeconds("GRANITE_QUERY_TIMEOUT")
+	cfg.SlowQueryThreshold = envMilliseconds("GRANITE_SLOW_QUERY_MS")
+
+	cfg.ConnectTimeout = envSeconds("GRANITE_CONNECT_TIMEOUT")
+
+	if cfg.ConnectTimeout == 0 {
+		cfg.ConnectTimeout = 30 * time.Second
+	}
+
+	cfg.MaxBodySize = envMegabytes("GRANITE_MAX_BODY_MB", 10)
+	cfg.MaxUploadSize = envMegabytes("GRANITE_MAX_UPLOAD_MB", 512)
+	cfg.MultipartMemory = envMegabytes("GRANITE_MULTIPART_MEMORY_MB", 32)
+
+	cfg.MaxQueryLength = envInt("GRANITE_MAX_QUERY_LENGTH", 1<<20)
+	cfg.MaxStatements = envInt("GRANITE_MAX_STATEMENTS", 1000)
+
+	cfg.MaxTransfers = envInt("GRANITE_MAX_TRANSFERS", 8)
+
+	cfg.ListPageSize = envInt("GRANITE_LIST_PAGE_SIZE", 100)
+	cfg.MaxListPageSize = envInt("GRANITE_MAX_LIST_PAGE_SIZE", 1000)
+}
+
 func applyOpenAIConfig(cfg *Config) {
 	baseURL := os.Getenv("OPENAI_BASE_URL")
 	apiKey := os.Getenv("OPENAI_API_KEY")
@@ -45,3 +101,47 @@ func applyOpenAIConfig(cfg *Config) {
 		Model: model,
 	}
 }
+
+// envSeconds reads an environment variable holding a number of seconds
+func envSeconds(key string) time.Duration {
+	value, err := strconv.Atoi(os.Getenv(key))
+
+	if err != nil || value <= 0 {
+		return 0
+	}
+
+	return time.Duration(value) * time.Second
+}
+
+// envMilliseconds reads an environment variable holding a number of milliseconds
+func envMilliseconds(key string) time.Duration {
+	value, err := strconv.Atoi(os.Getenv(key))
+
+	if err != nil || value <= 0 {
+		return 0
+	}
+
+	return time.Duration(value) * time.Millisecond
+}
+
+// envMegabytes reads an environment variable holding a size in megabytes
+func envMegabytes(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+
+	if err != nil || value <= 0 {
+		value = fallback
+	}
+
+	return value << 20
+}
+
+// envInt reads an environment variable holding a non-negative number
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+
+	if err != nil || value < 0 {
+		return fallback
+	}
+
+	return value
+}
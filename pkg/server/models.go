@@ -5,6 +5,7 @@ import (
 
 	"github.com/adrianliechti/granite/pkg/storage/azblob"
 	"github.com/adrianliechti/granite/pkg/storage/s3"
+	"github.com/adrianliechti/granite/pkg/tunnel"
 )
 
 type Config struct {
@@ -17,13 +18,19 @@ type AIConfig struct {
 
 type ErrorResponse struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"` // Normalized error category, e.g. "NotFound" or "Timeout"
 }
 
 // Connection represents a database or storage connection configuration
 type Connection struct {
-	ID   string `json:"id"`
+	ID   string `json:"id"` // case-insensitive, stored in lower case (see normalizeConnectionID)
 	Name string `json:"name"`
 
+	// Optional: deployment environment ("production", "staging", "dev") and display color.
+	// Destructive statements on production connections must be confirmed explicitly.
+	Environment string `json:"environment,omitempty"`
+	Color       string `json:"color,omitempty"`
+
 	// SQL connection
 	SQL *SQLConfig `json:"sql,omitempty"`
 
@@ -31,25 +38,126 @@ type Connection struct {
 	AmazonS3  *s3.Config     `json:"amazonS3,omitempty"`
 	AzureBlob *azblob.Config `json:"azureBlob,omitempty"`
 
+	// Optional: deleted objects are moved to a .trash/ prefix from where they can be restored
+	Trash bool `json:"trash,omitempty"`
+
 	CreatedAt *time.Time `json:"createdAt,omitempty"`
 	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
 }
 
+// PingResponse reports whether a saved connection is reachable
+type PingResponse struct {
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+
+	// DSN is the connection string a SQL connection is opened with, passwords masked
+	DSN string `json:"dsn,omitempty"`
+}
+
 // SQLConfig contains SQL database connection configuration
 type SQLConfig struct {
-	Driver string `json:"driver"` // "postgres", "mysql", "sqlite", "sqlserver", "oracle", "trino"
+	Driver string `json:"driver"` // "postgres", "pgx", "mysql", "sqlite", "sqlserver", "oracle", "trino"
 	DSN    string `json:"dsn"`
+
+	// Optional: reach the database through an SSH tunnel (bastion host)
+	SSH *tunnel.Config `json:"ssh,omitempty"`
+
+	// Optional: SQLite pragmas, appended to the DSN unless it sets them itself
+	SQLite *SQLiteConfig `json:"sqlite,omitempty"`
+
+	// Optional: driver parameters (e.g. application_name, charset) merged into the DSN
+	Params map[string]string `json:"params,omitempty"`
+
+	DefaultTimeoutSeconds int `json:"defaultTimeoutSeconds,omitempty"` // Optional: timeout for requests that don't specify one
+	ConnectTimeoutSeconds int `json:"connectTimeoutSeconds,omitempty"` // Optional: timeout for establishing the connection
+
+	// Optional: statement types (e.g. "SELECT", "INSERT") permitted or rejected on this connection
+	AllowedStatements []string `json:"allowedStatements,omitempty"`
+	DeniedStatements  []string `json:"deniedStatements,omitempty"`
+
+	// Optional: roles requests may impersonate (SET ROLE / EXECUTE AS USER), none if empty
+	AllowedRoles []string `json:"allowedRoles,omitempty"`
+
+	// Optional: result columns whose values are replaced by "***", e.g. to hide PII.
+	// Entries are column names or regular expressions in slashes ("/^ssn$/"), matched
+	// case-insensitively against the names of the result columns.
+	MaskedColumns []string `json:"maskedColumns,omitempty"`
+
+	// Optional: run queries in read-only transactions that are rolled back afterwards,
+	// so that they cannot write even through functions with side effects
+	// (PostgreSQL, MySQL and Oracle). Endpoints that write (execute, import and
+	// terminating sessions) are refused.
+	ReadOnlyTransactions bool `json:"readOnlyTransactions,omitempty"`
+}
+
+// SQLiteConfig contains pragmas applied to every SQLite connection
+type SQLiteConfig struct {
+	BusyTimeoutMs int    `json:"busyTimeoutMs,omitempty"` // Optional: wait this long for locks instead of failing with "database is locked" (default 5000)
+	JournalMode   string `json:"journalMode,omitempty"`   // Optional: "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL" or "OFF"
+	ForeignKeys   *bool  `json:"foreignKeys,omitempty"`   // Optional: enforce foreign key constraints
 }
 
 type SQLRequest struct {
 	Query    string `json:"query"`
 	Params   []any  `json:"params"`
 	Database string `json:"database,omitempty"` // Optional: specify which database to query
+
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"` // Optional: overrides the connection and server default timeout
+
+	Format string `json:"format,omitempty"` // Optional: "objects" (default) or "arrays" for positional rows
+
+	// Optional: paging, rows before offset are skipped and at most limit rows are returned
+	Offset int `json:"offset,omitempty"`
+	Limit  int `json:"limit,omitempty"`
+
+	Count bool `json:"count,omitempty"` // Optional: also compute the total row count (costs an extra query)
+
+	TimeZone          string `json:"timeZone,omitempty"`          // Optional: IANA time zone to convert time values into (e.g. "Europe/Zurich")
+	DecimalsAsStrings bool   `json:"decimalsAsStrings,omitempty"` // Optional: return decimal/numeric columns as strings to keep their precision
+
+	Columns []string `json:"columns,omitempty"` // Optional: only return these result columns
+
+	Confirm bool `json:"confirm,omitempty"` // Confirms destructive statements on production connections
+
+	Role string `json:"role,omitempty"` // Optional: run as this role, see SQLConfig.AllowedRoles
+
+	ReadOnly bool `json:"readOnly,omitempty"` // Optional: run queries in a read-only transaction, see SQLConfig.ReadOnlyTransactions
+
+	// Optional: rewrite $1, ?, :1 or @p1 placeholders to the style of the connection's driver
+	NormalizePlaceholders bool `json:"normalizePlaceholders,omitempty"`
+
+	// Optional (execute): run a script statement by statement and handle failures with
+	// "stop" (roll back all), "continue" (skip failing statements) or "rollbackStatement"
+	// (roll failing statements back to a savepoint, commit the others)
+	OnError string `json:"onError,omitempty"`
 }
 
 type SQLResponse struct {
-	Columns      []string         `json:"columns,omitempty"`
-	Rows         []map[string]any `json:"rows,omitempty"`
-	RowsAffected int64            `json:"rows_affected,omitempty"`
-	Error        string           `json:"error,omitempty"`
+	Columns      []string `json:"columns,omitempty"`
+	Rows         any      `json:"rows,omitempty"` // []map[string]any, or [][]any for format "arrays"
+	RowsAffected int64    `json:"rows_affected,omitempty"`
+	Error        string   `json:"error,omitempty"`
+
+	// OutParams holds the values of OUT parameters of a procedure call, keyed by name or 1-based position
+	OutParams map[string]any `json:"outParams,omitempty"`
+
+	// ResultSets holds every result set when a statement returns more than one
+	// (e.g. stored procedures); Columns and Rows always mirror the first one
+	ResultSets []SQLResultSet `json:"resultSets,omitempty"`
+
+	// Statements reports the outcome of each statement of a script run with onError
+	Statements []StatementResult `json:"statements,omitempty"`
+
+	// Paging: the offset of the first returned row, whether more rows follow
+	// and, if requested, the total row count of the query
+	RowStart  *int   `json:"rowStart,omitempty"`
+	HasMore   bool   `json:"hasMore,omitempty"`
+	TotalRows *int64 `json:"totalRows,omitempty"`
+}
+
+type SQLResultSet struct {
+	Columns []string `json:"columns"`
+	Rows    any      `json:"rows"`
+	HasMore bool     `json:"hasMore,omitempty"`
 }
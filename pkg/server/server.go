@@ -3,6 +3,8 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"log"
@@ -11,11 +13,13 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/adrianliechti/granite"
 	"github.com/adrianliechti/granite/pkg/config"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/lib/pq"
 	_ "github.com/microsoft/go-mssqldb"
 	_ "github.com/microsoft/go-mssqldb/integratedauth/krb5"
@@ -26,35 +30,111 @@ import (
 
 type Server struct {
 	http.Handler
+
+	config *config.Config
+
+	schemas *schemaCache
+
+	// uploads tracks the multipart uploads in progress
+	uploads *uploadSessions
+
+	// transfers limits concurrent storage uploads and downloads (nil = unlimited)
+	transfers chan struct{}
+
+	metrics *metrics
+
+	// favoritesMu guards updates of the per-connection favorites files
+	favoritesMu sync.Mutex
 }
 
 func New(cfg *config.Config) (*Server, error) {
+	if err := migrateConnectionIDs(); err != nil {
+		return nil, err
+	}
+
 	mux := http.NewServeMux()
 
 	s := &Server{
-		Handler: mux,
+		config: cfg,
+
+		schemas: newSchemaCache(),
+		uploads: newUploadSessions(),
+		metrics: newMetrics(),
 	}
 
+	s.Handler = s.metrics.instrument(mux)
+
+	if cfg.MaxTransfers > 0 {
+		s.transfers = make(chan struct{}, cfg.MaxTransfers)
+	}
+
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+
 	// Connection endpoints
+	mux.HandleFunc("GET /providers", s.handleProviders)
+	mux.HandleFunc("GET /drivers", s.handleDrivers)
+
 	mux.HandleFunc("GET /connections", s.handleConnectionList)
 	mux.HandleFunc("POST /connections", s.handleConnectionCreate)
 	mux.HandleFunc("GET /connections/{id}", s.handleConnectionGet)
 	mux.HandleFunc("PUT /connections/{id}", s.handleConnectionUpdate)
 	mux.HandleFunc("DELETE /connections/{id}", s.handleConnectionDelete)
+	mux.HandleFunc("POST /connections/{id}/ping", s.handleConnectionPing)
+	mux.HandleFunc("POST /connections/{id}/browse", s.handleConnectionBrowse)
+	mux.HandleFunc("POST /connections/{id}/schema/refresh", s.handleSchemaRefresh)
+	mux.HandleFunc("GET /connections/{id}/favorites", s.handleFavoriteList)
+	mux.HandleFunc("POST /connections/{id}/favorites/toggle", s.handleFavoriteToggle)
 
 	// SQL endpoints
+	mux.HandleFunc("POST /sql/fanout", s.handleFanout)
+	mux.HandleFunc("POST /sql/schema/diff", s.handleSchemaDiff)
 	mux.HandleFunc("POST /sql/{connection}/query", s.handleQuery)
+	mux.HandleFunc("POST /sql/{connection}/query/export", s.handleExport)
+	mux.HandleFunc("POST /sql/{connection}/query/count", s.handleQueryCount)
+	mux.HandleFunc("POST /sql/{connection}/pivot", s.handlePivot)
+	mux.HandleFunc("POST /sql/{connection}/benchmark", s.handleBenchmark)
+	mux.HandleFunc("POST /sql/{connection}/explain", s.handleExplain)
 	mux.HandleFunc("POST /sql/{connection}/execute", s.handleExecute)
+	mux.HandleFunc("POST /sql/{connection}/preview", s.handlePreview)
+	mux.HandleFunc("POST /sql/{connection}/validate", s.handleValidate)
+	mux.HandleFunc("POST /sql/{connection}/import", s.handleImport)
+	mux.HandleFunc("POST /sql/{connection}/schema", s.handleSchema)
+	mux.HandleFunc("POST /sql/{connection}/complete", s.handleComplete)
+	mux.HandleFunc("POST /sql/{connection}/table/ddl", s.handleTableDDL)
+	mux.HandleFunc("POST /sql/{connection}/table/export", s.handleTableExport)
+	mux.HandleFunc("POST /sql/{connection}/dump", s.handleDump)
+	mux.HandleFunc("POST /sql/{connection}/tables/sample", s.handleSampleTables)
+	mux.HandleFunc("GET /sql/{connection}/listen", s.handleListen)
+	mux.HandleFunc("POST /sql/{connection}/sessions", s.handleSessions)
+	mux.HandleFunc("POST /sql/{connection}/sessions/kill", s.handleKillSession)
+	mux.HandleFunc("POST /sql/{connection}/roles", s.handleRoles)
 
 	// Storage endpoints
 	mux.HandleFunc("POST /storage/{connection}/containers", s.handleStorageContainers)
 	mux.HandleFunc("POST /storage/{connection}/containers/create", s.handleStorageCreateContainer)
+	mux.HandleFunc("POST /storage/{connection}/containers/exists", s.handleStorageContainerExists)
 
 	mux.HandleFunc("POST /storage/{connection}/objects", s.handleStorageObjects)
+	mux.HandleFunc("POST /storage/{connection}/objects/search", s.handleStorageSearchObjects)
 	mux.HandleFunc("POST /storage/{connection}/object/details", s.handleStorageObjectDetails)
+	mux.HandleFunc("POST /storage/{connection}/object/checksum", s.handleStorageObjectChecksum)
+	mux.HandleFunc("POST /storage/{connection}/object/range", s.handleStorageObjectRange)
 	mux.HandleFunc("POST /storage/{connection}/object/presign", s.handleStoragePresignedURL)
+	mux.HandleFunc("GET /storage/{connection}/object/download", s.handleStorageDownloadObject)
 	mux.HandleFunc("POST /storage/{connection}/object/delete", s.handleStorageDeleteObject)
+	mux.HandleFunc("POST /storage/{connection}/trash/restore", s.handleStorageRestoreObjects)
+	mux.HandleFunc("POST /storage/{connection}/trash/empty", s.handleStorageEmptyTrash)
+	mux.HandleFunc("POST /storage/{connection}/object/tier", s.handleStorageSetAccessTier)
+	mux.HandleFunc("POST /storage/{connection}/object/undelete", s.handleStorageUndeleteObject)
+	mux.HandleFunc("POST /storage/{connection}/object/update", s.handleStorageUpdateObject)
 	mux.HandleFunc("POST /storage/{connection}/upload", s.handleStorageUploadObject)
+	mux.HandleFunc("PUT /storage/{connection}/object", s.handleStoragePutObject)
+	mux.HandleFunc("POST /storage/{connection}/multipart", s.handleStorageCreateMultipartUpload)
+	mux.HandleFunc("GET /storage/{connection}/multipart/{upload}", s.handleStorageMultipartUpload)
+	mux.HandleFunc("PUT /storage/{connection}/multipart/{upload}/{part}", s.handleStorageUploadPart)
+	mux.HandleFunc("POST /storage/{connection}/multipart/{upload}/complete", s.handleStorageCompleteMultipartUpload)
+	mux.HandleFunc("DELETE /storage/{connection}/multipart/{upload}", s.handleStorageAbortMultipartUpload)
+	mux.HandleFunc("POST /storage/{connection}/download-zip", s.handleStorageDownloadZip)
 
 	if cfg.OpenAI != nil {
 		target, err := url.Parse(cfg.OpenAI.URL)
@@ -98,9 +178,7 @@ func New(cfg *config.Config) (*Server, error) {
 
 	mux.Handle("/", spaHandler(granite.DistFS))
 
-	return &Server{
-		Handler: mux,
-	}, nil
+	return s, nil
 }
 
 func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
@@ -124,7 +202,20 @@ func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
 func writeError(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(ErrorResponse{Message: message})
+	json.NewEncoder(w).Encode(ErrorResponse{Message: message, Code: codeForStatus(status)})
+}
+
+// writeDecodeError reports a request body that could not be read, using 413
+// when the body exceeded its size limit and 400 otherwise
+func writeDecodeError(w http.ResponseWriter, err error, message string) {
+	var maxBytesErr *http.MaxBytesError
+
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+		return
+	}
+
+	writeError(w, http.StatusBadRequest, message)
 }
 
 func spaHandler(fsys fs.FS) http.Handler {
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// BrowseResponse is the top-level listing of a connection
+type BrowseResponse struct {
+	Type string `json:"type"` // "sql" or "storage"
+	Kind string `json:"kind"` // "databases" or "containers"
+
+	Items []BrowseItem `json:"items"`
+}
+
+// BrowseItem is a database or container
+type BrowseItem struct {
+	Name string `json:"name"`
+}
+
+// POST /connections/{id}/browse - List the databases of a SQL connection or the containers of a storage connection
+func (s *Server) handleConnectionBrowse(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	conn, err := s.getConnection(id)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	resp := BrowseResponse{
+		Items: make([]BrowseItem, 0),
+	}
+
+	switch {
+	case conn.SQL != nil:
+		resp.Type = "sql"
+		resp.Kind = "databases"
+
+		db, err := s.openDatabase(ctx, conn, "")
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer db.Close()
+
+		names, err := listDatabases(ctx, db, conn.SQL.Driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		for _, name := range names {
+			resp.Items = append(resp.Items, BrowseItem{Name: name})
+		}
+
+	case conn.AmazonS3 != nil || conn.AzureBlob != nil:
+		resp.Type = "storage"
+		resp.Kind = "containers"
+
+		provider, err := newStorageProviderFromConnection(ctx, conn)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		containers, err := provider.ListContainers(ctx)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, c := range containers {
+			resp.Items = append(resp.Items, BrowseItem{Name: c.Name})
+		}
+
+	default:
+		writeError(w, http.StatusBadRequest, "connection has no provider configured")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// listDatabases returns the databases of a SQL server. Oracle lists the current
+// container and Trino its catalog.schema pairs, as used for switching databases.
+func listDatabases(ctx context.Context, db *sql.DB, driver string) ([]string, error) {
+	var query string
+
+	switch driver {
+	case "postgres", "pgx":
+		query = `SELECT datname FROM pg_database WHERE datistemplate = false ORDER BY datname`
+
+	case "mysql":
+		query = `SHOW DATABASES`
+
+	case "sqlserver":
+		query = `SELECT name FROM sys.databases WHERE name NOT IN ('tempdb', 'model') ORDER BY name`
+
+	case "oracle":
+		query = `SELECT SYS_CONTEXT('USERENV', 'CON_NAME') FROM DUAL`
+
+	case "sqlite":
+		// a SQLite file holds a single database
+		return []string{"main"}, nil
+
+	case "trino":
+		query = `
+			SELECT table_catalog || '.' || table_schem
+			FROM system.jdbc.schemas
+			WHERE table_schem NOT IN ('information_schema')
+			ORDER BY 1`
+
+	default:
+		return nil, fmt.Errorf("listing databases is not supported for driver %q", driver)
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	names := make([]string, 0)
+
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"os"
+	"time"
 )
 
 // GET /connections - List all connections
@@ -11,7 +13,7 @@ func (s *Server) handleConnectionList(w http.ResponseWriter, r *http.Request) {
 	connections, err := s.listConnections()
 
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -31,7 +33,7 @@ func (s *Server) handleConnectionGet(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -43,11 +45,15 @@ func (s *Server) handleConnectionGet(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleConnectionCreate(w http.ResponseWriter, r *http.Request) {
 	var conn Connection
 
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
 	if err := json.NewDecoder(r.Body).Decode(&conn); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeDecodeError(w, err, "invalid request body")
 		return
 	}
 
+	conn.ID = normalizeConnectionID(conn.ID)
+
 	if conn.ID == "" {
 		writeError(w, http.StatusBadRequest, "id is required")
 		return
@@ -83,7 +89,7 @@ func (s *Server) handleConnectionCreate(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := s.saveConnection(&conn); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -103,19 +109,21 @@ func (s *Server) handleConnectionUpdate(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	var conn Connection
 
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
 	if err := json.NewDecoder(r.Body).Decode(&conn); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeDecodeError(w, err, "invalid request body")
 		return
 	}
 
 	// Ensure ID matches path
-	conn.ID = id
+	conn.ID = normalizeConnectionID(id)
 
 	if conn.Name == "" {
 		writeError(w, http.StatusBadRequest, "name is required")
@@ -141,15 +149,17 @@ func (s *Server) handleConnectionUpdate(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := s.saveConnection(&conn); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	s.schemas.invalidate(id)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(conn)
 }
 
-// DELETE /connections/{id} - Delete a connection
+// DELETE /connections/{id}?favorites=delete - Delete a connection (and optionally its favorite queries)
 func (s *Server) handleConnectionDelete(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
@@ -159,9 +169,84 @@ func (s *Server) handleConnectionDelete(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	s.schemas.invalidate(id)
+
+	// Optionally drop the favorite queries saved for the connection as well
+	if r.URL.Query().Get("favorites") == "delete" {
+		s.favoritesMu.Lock()
+		err := s.deleteFavorites(id)
+		s.favoritesMu.Unlock()
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// POST /connections/{id}/ping - Check whether a saved connection is reachable
+func (s *Server) handleConnectionPing(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	conn, err := s.getConnection(id)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	start := time.Now()
+	err = s.pingConnection(r.Context(), conn)
+
+	resp := PingResponse{
+		OK:        err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	// The DSN after applying the connection's settings helps diagnose failures
+	if conn.SQL != nil {
+		if dsn, err := effectiveDSN(conn.SQL, ""); err == nil {
+			resp.DSN = maskDSN(conn.SQL.Driver, dsn)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// pingConnection opens the connection's provider and performs a cheap round-trip
+func (s *Server) pingConnection(ctx context.Context, conn *Connection) error {
+	if conn.SQL != nil {
+		db, err := s.openDatabase(ctx, conn, "")
+
+		if err != nil {
+			return err
+		}
+
+		return db.Close()
+	}
+
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = provider.ListContainers(ctx)
+	return err
+}
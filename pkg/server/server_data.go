@@ -2,13 +2,23 @@ package server
 
 import (
 	"encoding/json"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// normalizeConnectionID returns the canonical form of a connection ID. IDs are
+// case-insensitive and stored in lower case without surrounding whitespace, so
+// "MyConn" and "myconn" refer to the same connection.
+func normalizeConnectionID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
 // getConnection retrieves a connection configuration by ID
 func (s *Server) getConnection(id string) (*Connection, error) {
+	id = normalizeConnectionID(id)
+
 	filePath := filepath.Join(getDataDir(), "connections", id+".json")
 
 	data, err := os.ReadFile(filePath)
@@ -33,6 +43,8 @@ func (s *Server) saveConnection(conn *Connection) error {
 		return err
 	}
 
+	conn.ID = normalizeConnectionID(conn.ID)
+
 	data, err := json.Marshal(conn)
 	if err != nil {
 		return err
@@ -44,7 +56,7 @@ func (s *Server) saveConnection(conn *Connection) error {
 
 // deleteConnection deletes a connection configuration
 func (s *Server) deleteConnection(id string) error {
-	filePath := filepath.Join(getDataDir(), "connections", id+".json")
+	filePath := filepath.Join(getDataDir(), "connections", normalizeConnectionID(id)+".json")
 	return os.Remove(filePath)
 }
 
@@ -73,6 +85,11 @@ func (s *Server) listConnections() ([]Connection, error) {
 
 		id := strings.TrimSuffix(entry.Name(), ".json")
 
+		// Files that could not be migrated to their canonical name are unreachable
+		if id != normalizeConnectionID(id) {
+			continue
+		}
+
 		conn, err := s.getConnection(id)
 		if err != nil {
 			continue
@@ -89,6 +106,63 @@ func (s *Server) listConnections() ([]Connection, error) {
 	return connections, nil
 }
 
+// migrateConnectionIDs renames the connection and favorites files of IDs that are
+// not in canonical form. Files whose canonical name is already taken are left
+// unchanged and reported, as merging them would lose one of the configurations.
+func migrateConnectionIDs() error {
+	for _, kind := range []string{"connections", "favorites"} {
+		dir := filepath.Join(getDataDir(), kind)
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+				continue
+			}
+
+			id := strings.TrimSuffix(entry.Name(), ".json")
+			normalized := normalizeConnectionID(id)
+
+			if id == normalized {
+				continue
+			}
+
+			source := filepath.Join(dir, entry.Name())
+			target := filepath.Join(dir, normalized+".json")
+
+			sourceInfo, err := os.Stat(source)
+			if err != nil {
+				return err
+			}
+
+			// On case-insensitive file systems (e.g. macOS) the target is the source itself
+			if targetInfo, err := os.Stat(target); err == nil && !os.SameFile(sourceInfo, targetInfo) {
+				slog.Warn("connection ID conflicts with an existing connection, file left unchanged", "kind", kind, "id", id, "conflict", normalized)
+				continue
+			}
+
+			// Renaming through a temporary name changes the case on those file systems as well
+			temp := target + ".migrating"
+
+			if err := os.Rename(source, temp); err != nil {
+				return err
+			}
+
+			if err := os.Rename(temp, target); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func getDataDir() string {
 	home, err := os.UserHomeDir()
 
@@ -98,3 +172,51 @@ func getDataDir() string {
 
 	return filepath.Join(home, ".local", "share", "granite")
 }
+
+// getFavorites returns the favorite queries saved for a connection
+func (s *Server) getFavorites(connID string) ([]Favorite, error) {
+	filePath := filepath.Join(getDataDir(), "favorites", normalizeConnectionID(connID)+".json")
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Favorite{}, nil
+		}
+		return nil, err
+	}
+
+	favorites := make([]Favorite, 0)
+	if err := json.Unmarshal(data, &favorites); err != nil {
+		return nil, err
+	}
+
+	return favorites, nil
+}
+
+// saveFavorites saves the favorite queries of a connection
+func (s *Server) saveFavorites(connID string, favorites []Favorite) error {
+	dir := filepath.Join(getDataDir(), "favorites")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(favorites)
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(dir, normalizeConnectionID(connID)+".json")
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// deleteFavorites deletes the favorite queries of a connection
+func (s *Server) deleteFavorites(connID string) error {
+	filePath := filepath.Join(getDataDir(), "favorites", normalizeConnectionID(connID)+".json")
+
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/sijms/go-ora/v2/network"
+)
+
+// Normalized error codes returned alongside error messages, so clients can
+// react to failures without parsing driver or SDK specific messages
+const (
+	ErrorCodeBadRequest   = "BadRequest"
+	ErrorCodeNotFound     = "NotFound"
+	ErrorCodeUnauthorized = "Unauthorized"
+	ErrorCodeTimeout      = "Timeout"
+	ErrorCodeConflict     = "Conflict"
+	ErrorCodeUnavailable  = "Unavailable"
+	ErrorCodeInternal     = "Internal"
+)
+
+// writeErrorFrom writes an error response for err. Errors that can be classified
+// (e.g. a missing table or denied access) get a matching status, all others
+// the given fallback status.
+func writeErrorFrom(w http.ResponseWriter, status int, err error) {
+	if code, ok := classifyError(err); ok {
+		status = statusForCode(code, status)
+	}
+
+	writeError(w, status, err.Error())
+}
+
+// codeForStatus returns the error code of an HTTP status
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorCodeUnauthorized
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrorCodeTimeout
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return ErrorCodeConflict
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return ErrorCodeUnavailable
+	}
+
+	if status >= 400 && status < 500 {
+		return ErrorCodeBadRequest
+	}
+
+	return ErrorCodeInternal
+}
+
+// statusForCode returns the HTTP status reported for an error code. Statuses
+// that already belong to the code (e.g. 412 for Conflict) are kept.
+func statusForCode(code string, status int) int {
+	if codeForStatus(status) == code {
+		return status
+	}
+
+	switch code {
+	case ErrorCodeNotFound:
+		return http.StatusNotFound
+	case ErrorCodeUnauthorized:
+		return http.StatusForbidden
+	case ErrorCodeTimeout:
+		return http.StatusGatewayTimeout
+	case ErrorCodeConflict:
+		return http.StatusConflict
+	case ErrorCodeUnavailable:
+		return http.StatusServiceUnavailable
+	case ErrorCodeBadRequest:
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// classifyError maps driver and SDK errors to a normalized error code
+func classifyError(err error) (string, bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeTimeout, true
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, sql.ErrNoRows):
+		return ErrorCodeNotFound, true
+	case errors.Is(err, storage.ErrAccessDenied):
+		return ErrorCodeUnauthorized, true
+	case errors.Is(err, storage.ErrPreconditionFailed):
+		return ErrorCodeConflict, true
+	case errors.Is(err, errors.ErrUnsupported):
+		return ErrorCodeBadRequest, true
+	case errors.Is(err, driver.ErrBadConn):
+		return ErrorCodeUnavailable, true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return sqlStateCode(string(pqErr.Code))
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return sqlStateCode(pgErr.Code)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErrorCode(mysqlErr.Number)
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		return mssqlErrorCode(mssqlErr.Number)
+	}
+
+	var oraErr *network.OracleError
+	if errors.As(err, &oraErr) {
+		return oracleErrorCode(oraErr.ErrCode)
+	}
+
+	// S3 (and other smithy based SDK) errors carry the HTTP response status
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return codeForStatus(statusErr.HTTPStatusCode()), true
+	}
+
+	var azErr *azcore.ResponseError
+	if errors.As(err, &azErr) {
+		return codeForStatus(azErr.StatusCode), true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorCodeTimeout, true
+		}
+
+		return ErrorCodeUnavailable, true
+	}
+
+	return "", false
+}
+
+// sqlStateCode classifies an SQLSTATE as used by PostgreSQL
+func sqlStateCode(state string) (string, bool) {
+	switch state {
+	case "42501":
+		return ErrorCodeUnauthorized, true
+	case "3D000", "3F000", "42P01", "42704", "42883":
+		return ErrorCodeNotFound, true
+	case "57014":
+		return ErrorCodeTimeout, true
+	case "40001", "40P01", "55P03":
+		return ErrorCodeConflict, true
+	}
+
+	switch {
+	case strings.HasPrefix(state, "28"):
+		return ErrorCodeUnauthorized, true
+	case strings.HasPrefix(state, "23"):
+		return ErrorCodeConflict, true
+	case strings.HasPrefix(state, "08"), strings.HasPrefix(state, "53"), strings.HasPrefix(state, "57P"):
+		return ErrorCodeUnavailable, true
+	case strings.HasPrefix(state, "22"), strings.HasPrefix(state, "42"):
+		return ErrorCodeBadRequest, true
+	}
+
+	return "", false
+}
+
+// mysqlErrorCode classifies a MySQL server error number
+func mysqlErrorCode(number uint16) (string, bool) {
+	switch number {
+	case 1044, 1045, 1142, 1143, 1227:
+		return ErrorCodeUnauthorized, true
+	case 1049, 1146:
+		return ErrorCodeNotFound, true
+	case 1205, 3024:
+		return ErrorCodeTimeout, true
+	case 1062, 1213, 1451, 1452:
+		return ErrorCodeConflict, true
+	case 1040, 1053:
+		return ErrorCodeUnavailable, true
+	case 1054, 1064:
+		return ErrorCodeBadRequest, true
+	}
+
+	return "", false
+}
+
+// mssqlErrorCode classifies a SQL Server error number
+func mssqlErrorCode(number int32) (string, bool) {
+	switch number {
+	case 229, 230, 262, 18456:
+		return ErrorCodeUnauthorized, true
+	case 208, 4060:
+		return ErrorCodeNotFound, true
+	case 547, 1205, 2601, 2627:
+		return ErrorCodeConflict, true
+	case 102, 207:
+		return ErrorCodeBadRequest, true
+	}
+
+	return "", false
+}
+
+// oracleErrorCode classifies an ORA- error number
+func oracleErrorCode(number int) (string, bool) {
+	switch number {
+	case 1017, 1031:
+		return ErrorCodeUnauthorized, true
+	case 942:
+		return ErrorCodeNotFound, true
+	case 1013:
+		return ErrorCodeTimeout, true
+	case 1, 60, 2291, 2292:
+		return ErrorCodeConflict, true
+	case 12514, 12541, 12543:
+		return ErrorCodeUnavailable, true
+	case 900, 904, 933:
+		return ErrorCodeBadRequest, true
+	}
+
+	return "", false
+}
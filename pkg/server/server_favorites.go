@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Favorite is a bookmarked query of a connection
+type Favorite struct {
+	Query string `json:"query"`
+	Name  string `json:"name,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ToggleFavoriteRequest contains the query to add to or remove from the favorites
+type ToggleFavoriteRequest struct {
+	Query string `json:"query"`
+	Name  string `json:"name,omitempty"`
+}
+
+// ToggleFavoriteResponse reports whether the query is a favorite after toggling
+type ToggleFavoriteResponse struct {
+	Favorite  bool       `json:"favorite"`
+	Favorites []Favorite `json:"favorites"`
+}
+
+// GET /connections/{id}/favorites - List the favorite queries of a connection
+func (s *Server) handleFavoriteList(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if _, err := s.getConnection(id); err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	favorites, err := s.getFavorites(id)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(favorites)
+}
+
+// POST /connections/{id}/favorites/toggle - Add a query to the favorites, or remove it if it already is one
+func (s *Server) handleFavoriteToggle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if _, err := s.getConnection(id); err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var req ToggleFavoriteRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	query := strings.TrimSpace(req.Query)
+
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	// serialize read-modify-write of the favorites file
+	s.favoritesMu.Lock()
+	defer s.favoritesMu.Unlock()
+
+	favorites, err := s.getFavorites(id)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	index := slices.IndexFunc(favorites, func(f Favorite) bool {
+		return f.Query == query
+	})
+
+	if index >= 0 {
+		favorites = slices.Delete(favorites, index, index+1)
+	} else {
+		favorites = append(favorites, Favorite{
+			Query: query,
+			Name:  strings.TrimSpace(req.Name),
+
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+
+	if err := s.saveFavorites(id, favorites); err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ToggleFavoriteResponse{
+		Favorite:  index < 0,
+		Favorites: favorites,
+	})
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// queryDurationBuckets are the upper bounds (in seconds) of the query duration histogram
+var queryDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// metrics is a minimal registry of the counters exposed in Prometheus text format
+type metrics struct {
+	mu sync.Mutex
+
+	requests map[requestKey]uint64
+	queries  map[string]*histogram
+
+	inFlight atomic.Int64
+
+	uploadBytes   atomic.Int64
+	downloadBytes atomic.Int64
+}
+
+type requestKey struct {
+	route  string
+	method string
+	code   int
+}
+
+type histogram struct {
+	buckets []uint64 // cumulative counts per bucket of queryDurationBuckets
+	count   uint64
+	sum     float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests: make(map[requestKey]uint64),
+		queries:  make(map[string]*histogram),
+	}
+}
+
+// instrument counts requests by route pattern, method and status code
+func (m *metrics) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		// the mux stores the matched pattern on the request
+		route := r.Pattern
+
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+
+		if route == "" {
+			route = "unmatched"
+		}
+
+		m.mu.Lock()
+		m.requests[requestKey{route: route, method: r.Method, code: rec.status}]++
+		m.mu.Unlock()
+	})
+}
+
+// observeQuery records the duration of a SQL statement ("query" or "execute")
+func (m *metrics) observeQuery(operation string, elapsed time.Duration) {
+	seconds := elapsed.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.queries[operation]
+
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(queryDurationBuckets))}
+		m.queries[operation] = h
+	}
+
+	for i, bound := range queryDurationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+
+	h.count++
+	h.sum += seconds
+}
+
+// writeTo writes all metrics in the Prometheus text exposition format
+func (m *metrics) writeTo(w io.Writer, activeTransfers int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP granite_http_requests_total Number of HTTP requests by route, method and status code.")
+	fmt.Fprintln(w, "# TYPE granite_http_requests_total counter")
+
+	keys := make([]requestKey, 0, len(m.requests))
+
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b requestKey) int {
+		return cmp.Or(strings.Compare(a.route, b.route), strings.Compare(a.method, b.method), cmp.Compare(a.code, b.code))
+	})
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "granite_http_requests_total{route=%q,method=%q,code=\"%d\"} %d\n", key.route, key.method, key.code, m.requests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP granite_http_requests_in_flight Number of HTTP requests currently being served.")
+	fmt.Fprintln(w, "# TYPE granite_http_requests_in_flight gauge")
+	fmt.Fprintf(w, "granite_http_requests_in_flight %d\n", m.inFlight.Load())
+
+	fmt.Fprintln(w, "# HELP granite_sql_query_duration_seconds Duration of SQL statements.")
+	fmt.Fprintln(w, "# TYPE granite_sql_query_duration_seconds histogram")
+
+	operations := make([]string, 0, len(m.queries))
+
+	for operation := range m.queries {
+		operations = append(operations, operation)
+	}
+
+	slices.Sort(operations)
+
+	for _, operation := range operations {
+		h := m.queries[operation]
+
+		for i, bound := range queryDurationBuckets {
+			fmt.Fprintf(w, "granite_sql_query_duration_seconds_bucket{operation=%q,le=%q} %d\n", operation, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+
+		fmt.Fprintf(w, "granite_sql_query_duration_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", operation, h.count)
+		fmt.Fprintf(w, "granite_sql_query_duration_seconds_sum{operation=%q} %g\n", operation, h.sum)
+		fmt.Fprintf(w, "granite_sql_query_duration_seconds_count{operation=%q} %d\n", operation, h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP granite_storage_transfers_active Number of storage uploads and downloads in progress.")
+	fmt.Fprintln(w, "# TYPE granite_storage_transfers_active gauge")
+	fmt.Fprintf(w, "granite_storage_transfers_active %d\n", activeTransfers)
+
+	fmt.Fprintln(w, "# HELP granite_storage_upload_bytes_total Bytes uploaded to storage.")
+	fmt.Fprintln(w, "# TYPE granite_storage_upload_bytes_total counter")
+	fmt.Fprintf(w, "granite_storage_upload_bytes_total %d\n", m.uploadBytes.Load())
+
+	fmt.Fprintln(w, "# HELP granite_storage_download_bytes_total Bytes downloaded from storage.")
+	fmt.Fprintln(w, "# TYPE granite_storage_download_bytes_total counter")
+	fmt.Fprintf(w, "granite_storage_download_bytes_total %d\n", m.downloadBytes.Load())
+}
+
+// GET /metrics - Expose server metrics in Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.writeTo(w, len(s.transfers))
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming responses (SSE, proxied AI responses) working
+func (r *statusRecorder) Flush() {
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+
+	count *atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
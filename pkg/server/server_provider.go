@@ -0,0 +1,160 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+	"github.com/adrianliechti/granite/pkg/storage/azblob"
+	"github.com/adrianliechti/granite/pkg/storage/s3"
+)
+
+// ProviderInfo describes a connection provider and the config fields it expects
+type ProviderInfo struct {
+	ID     string `json:"id"`     // e.g. "postgres", "s3", "azure-blob"
+	Kind   string `json:"kind"`   // "sql" or "storage"
+	Label  string `json:"label"`  // Display name
+	Config string `json:"config"` // Connection property holding the config ("sql", "amazonS3", "azureBlob")
+
+	Fields []storage.ConfigField `json:"fields"`
+
+	// ConnectionFields are set on the connection itself, next to the config
+	ConnectionFields []storage.ConfigField `json:"connectionFields"`
+}
+
+// DriverInfo describes a SQL driver and what it supports
+type DriverInfo struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+
+	Placeholder string `json:"placeholder"` // style of the first bind parameter, e.g. "$1", "?", "@p1" or ":1"
+
+	Returning          bool `json:"returning"`          // INSERT/UPDATE/DELETE ... RETURNING
+	MultipleResultSets bool `json:"multipleResultSets"` // statements returning more than one result set
+	Listen             bool `json:"listen"`             // LISTEN/NOTIFY notifications
+}
+
+// sqlDrivers lists the registered SQL drivers, their display names and capabilities
+var sqlDrivers = []DriverInfo{
+	{Name: "postgres", Label: "PostgreSQL", Returning: true, MultipleResultSets: true, Listen: true},
+	{Name: "pgx", Label: "PostgreSQL (pgx)", Returning: true, Listen: true},
+	{Name: "mysql", Label: "MySQL", MultipleResultSets: true},
+	{Name: "sqlserver", Label: "SQL Server", MultipleResultSets: true},
+	{Name: "oracle", Label: "Oracle"},
+	{Name: "sqlite", Label: "SQLite", Returning: true},
+	{Name: "trino", Label: "Trino"},
+}
+
+// connectionFields describes the fields of a Connection besides its ID, name and config
+func connectionFields(kind string) []storage.ConfigField {
+	fields := []storage.ConfigField{
+		{Name: "environment", Label: "Environment", Type: "string"},
+		{Name: "color", Label: "Color", Type: "string"},
+	}
+
+	if kind == "storage" {
+		fields = append(fields, storage.ConfigField{Name: "trash", Label: "Trash", Type: "boolean"})
+	}
+
+	return fields
+}
+
+// sqlConfigFields describes the fields of SQLConfig besides the driver
+func sqlConfigFields(driver string) []storage.ConfigField {
+	fields := []storage.ConfigField{
+		{Name: "dsn", Label: "Connection String", Type: "password", Required: true},
+		{Name: "ssh", Label: "SSH Tunnel", Type: "object", Fields: sshConfigFields()},
+		{Name: "params", Label: "Driver Parameters", Type: "map"},
+		{Name: "defaultTimeoutSeconds", Label: "Default Timeout (seconds)", Type: "number"},
+		{Name: "connectTimeoutSeconds", Label: "Connect Timeout (seconds)", Type: "number"},
+		{Name: "allowedStatements", Label: "Allowed Statements", Type: "list"},
+		{Name: "deniedStatements", Label: "Denied Statements", Type: "list"},
+		{Name: "allowedRoles", Label: "Allowed Roles", Type: "list"},
+		{Name: "maskedColumns", Label: "Masked Columns", Type: "list"},
+		{Name: "readOnlyTransactions", Label: "Read-Only Transactions", Type: "boolean"},
+	}
+
+	if driver == "sqlite" {
+		fields = append(fields, storage.ConfigField{Name: "sqlite", Label: "SQLite", Type: "object", Fields: []storage.ConfigField{
+			{Name: "busyTimeoutMs", Label: "Busy Timeout (ms)", Type: "number"},
+			{Name: "journalMode", Label: "Journal Mode", Type: "string"},
+			{Name: "foreignKeys", Label: "Foreign Keys", Type: "boolean"},
+		}})
+	}
+
+	return fields
+}
+
+// sshConfigFields describes the fields of tunnel.Config
+func sshConfigFields() []storage.ConfigField {
+	return []storage.ConfigField{
+		{Name: "host", Label: "Host", Type: "string", Required: true},
+		{Name: "username", Label: "Username", Type: "string", Required: true},
+		{Name: "password", Label: "Password", Type: "password"},
+		{Name: "privateKey", Label: "Private Key", Type: "password"},
+		{Name: "passphrase", Label: "Passphrase", Type: "password"},
+		{Name: "hostKey", Label: "Host Key", Type: "string"},
+	}
+}
+
+// GET /providers - List connection providers and their config fields
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	providers := make([]ProviderInfo, 0)
+
+	for _, driver := range sqlDrivers {
+		providers = append(providers, ProviderInfo{
+			ID:     driver.Name,
+			Kind:   "sql",
+			Label:  driver.Label,
+			Config: "sql",
+			Fields: sqlConfigFields(driver.Name),
+
+			ConnectionFields: connectionFields("sql"),
+		})
+	}
+
+	providers = append(providers,
+		ProviderInfo{
+			ID:     "s3",
+			Kind:   "storage",
+			Label:  "Amazon S3",
+			Config: "amazonS3",
+			Fields: s3.ConfigFields(),
+
+			ConnectionFields: connectionFields("storage"),
+		},
+		ProviderInfo{
+			ID:     "azure-blob",
+			Kind:   "storage",
+			Label:  "Azure Blob Storage",
+			Config: "azureBlob",
+			Fields: azblob.ConfigFields(),
+
+			ConnectionFields: connectionFields("storage"),
+		},
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(providers)
+}
+
+// GET /drivers - List the SQL drivers compiled into this build and their capabilities
+func (s *Server) handleDrivers(w http.ResponseWriter, r *http.Request) {
+	registered := sql.Drivers()
+
+	drivers := make([]DriverInfo, 0, len(sqlDrivers))
+
+	for _, driver := range sqlDrivers {
+		if !slices.Contains(registered, driver.Name) {
+			continue
+		}
+
+		driver.Placeholder = placeholder(driver.Name, 1)
+		drivers = append(drivers, driver)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(drivers)
+}
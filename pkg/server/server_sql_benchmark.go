@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// BenchmarkRequest contains a query to run repeatedly for timing
+type BenchmarkRequest struct {
+	SQLRequest
+
+	Runs int `json:"runs,omitempty"` // Optional: number of runs (default 5)
+}
+
+// BenchmarkResponse contains the execution times of a benchmarked query in milliseconds
+type BenchmarkResponse struct {
+	Runs int `json:"runs"`
+
+	MinMs float64 `json:"minMs"`
+	MaxMs float64 `json:"maxMs"`
+	AvgMs float64 `json:"avgMs"`
+
+	// TimingsMs holds the time of each run, in order
+	TimingsMs []float64 `json:"timingsMs"`
+
+	// RowsPerRun is the number of rows returned by a run (of all result sets)
+	RowsPerRun int64 `json:"rowsPerRun"`
+}
+
+const (
+	// benchmarkDefaultRuns is the number of runs unless the request specifies it
+	benchmarkDefaultRuns = 5
+
+	// benchmarkMaxRuns caps the number of runs of a single request
+	benchmarkMaxRuns = 100
+)
+
+// POST /sql/{connection}/benchmark - Run a query several times and report its execution
+// times. Rows are read and discarded, so the timings include fetching but not encoding.
+func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req BenchmarkRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	if req.Runs < 0 || req.Runs > benchmarkMaxRuns {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("runs must be between 1 and %d", benchmarkMaxRuns))
+		return
+	}
+
+	if req.Runs == 0 {
+		req.Runs = benchmarkDefaultRuns
+	}
+
+	// repeating a statement must not change data
+	for _, stmt := range splitStatements(conn.SQL.Driver, req.Query) {
+		switch keyword := statementType(conn.SQL.Driver, stmt); keyword {
+		case "SELECT", "VALUES", "TABLE", "SHOW":
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("%s statements cannot be benchmarked, only queries", keyword))
+			return
+		}
+	}
+
+	if err := rewritePlaceholders(conn.SQL.Driver, &req.SQLRequest); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := checkStatementPolicy(conn.SQL, req.Query); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkConfirmation(conn, &req.SQLRequest); err != nil {
+		writeErrorFrom(w, http.StatusPreconditionRequired, err)
+		return
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkReadOnly(conn.SQL, &req.SQLRequest); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params, err := bindParams(req.Params)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	var q queryer = session
+
+	if readOnlyQueries(conn.SQL, &req.SQLRequest) {
+		tx, err := beginReadOnly(ctx, session, conn.SQL.Driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	timeout := s.queryTimeout(conn, &req.SQLRequest)
+
+	resp := BenchmarkResponse{
+		Runs:      req.Runs,
+		TimingsMs: make([]float64, 0, req.Runs),
+	}
+
+	var total time.Duration
+
+	for i := range req.Runs {
+		elapsed, count, err := benchmarkRun(ctx, q, timeout, req.Query, params)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		s.metrics.observeQuery("query", elapsed)
+
+		ms := float64(elapsed) / float64(time.Millisecond)
+
+		if i == 0 || ms < resp.MinMs {
+			resp.MinMs = ms
+		}
+
+		if ms > resp.MaxMs {
+			resp.MaxMs = ms
+		}
+
+		total += elapsed
+
+		resp.TimingsMs = append(resp.TimingsMs, ms)
+		resp.RowsPerRun = count
+	}
+
+	resp.AvgMs = float64(total) / float64(req.Runs) / float64(time.Millisecond)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// benchmarkRun runs a query once, reading and discarding all rows of all result
+// sets, and returns its execution time and the number of rows
+func benchmarkRun(ctx context.Context, db queryer, timeout time.Duration, query string, params []any) (time.Duration, int64, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+
+	rows, err := db.QueryContext(ctx, query, params...)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer rows.Close()
+
+	var count int64
+
+	for {
+		for rows.Next() {
+			count++
+		}
+
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	return time.Since(start), count, nil
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// CountResponse contains the number of rows a query returns
+type CountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// POST /sql/{connection}/query/count - Count the rows of a query without fetching them
+func (s *Server) handleQueryCount(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req SQLRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := rewritePlaceholders(conn.SQL.Driver, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := checkStatementPolicy(conn.SQL, req.Query); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkReadOnly(conn.SQL, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params, err := bindParams(req.Params)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	var q queryer = session
+
+	if readOnlyQueries(conn.SQL, &req) {
+		tx, err := beginReadOnly(ctx, session, conn.SQL.Driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	count, err := countRows(ctx, q, conn.SQL.Driver, req.Query, params)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CountResponse{
+		Count: count,
+	})
+}
+
+// stripOrderBy removes a trailing ORDER BY clause, which does not change the
+// number of rows but is rejected in subqueries by some databases (e.g. SQL Server).
+// The clause is kept if it selects the rows together with LIMIT, OFFSET, FETCH or
+// TOP, or if it contains placeholders that are bound to parameters.
+func stripOrderBy(driver, stmt string) string {
+	tokens := topLevelTokens(driver, stmt)
+
+	order := -1
+
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].word == "ORDER" && tokens[i+1].word == "BY" {
+			order = i
+		}
+	}
+
+	if order < 0 {
+		return stmt
+	}
+
+	if slices.ContainsFunc(tokens, func(t sqlToken) bool {
+		return t.word == "TOP" || t.word == "LIMIT" || t.word == "OFFSET" || t.word == "FETCH"
+	}) {
+		return stmt
+	}
+
+	clause := stmt[tokens[order].start:]
+
+	if countPlaceholders(driver, clause) > 0 {
+		return stmt
+	}
+
+	return strings.TrimSpace(stmt[:tokens[order].start])
+}
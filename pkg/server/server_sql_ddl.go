@@ -0,0 +1,374 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TableRequest identifies a single table
+type TableRequest struct {
+	Table    string `json:"table"`
+	Schema   string `json:"schema,omitempty"`   // Optional: defaults to the driver's default schema
+	Database string `json:"database,omitempty"` // Optional: specify which database to query
+}
+
+// TableDDLResponse contains the CREATE statement of a table
+type TableDDLResponse struct {
+	DDL string `json:"ddl"`
+}
+
+// POST /sql/{connection}/table/ddl - Get the CREATE statement of a table
+func (s *Server) handleTableDDL(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req TableRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if req.Table == "" {
+		writeError(w, http.StatusBadRequest, "table is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	ddl, err := tableDDL(ctx, db, conn.SQL.Driver, req.Schema, req.Table)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TableDDLResponse{DDL: ddl})
+}
+
+// tableDDL returns the CREATE statement of a table using the driver's native
+// facility where one exists, or a reconstruction from the catalog otherwise
+func tableDDL(ctx context.Context, db *sql.DB, driver, schema, table string) (string, error) {
+	switch driver {
+	case "postgres", "pgx":
+		return postgresTableDDL(ctx, db, schema, table)
+
+	case "mysql":
+		return mysqlTableDDL(ctx, db, schema, table)
+
+	case "sqlite":
+		return sqliteTableDDL(ctx, db, table)
+
+	case "sqlserver":
+		return sqlserverTableDDL(ctx, db, schema, table)
+
+	case "oracle":
+		return oracleTableDDL(ctx, db, schema, table)
+
+	case "trino":
+		var ddl string
+		err := db.QueryRowContext(ctx, "SHOW CREATE TABLE "+quoteTableName(driver, schema, table)).Scan(&ddl)
+		return ddl, err
+	}
+
+	return "", fmt.Errorf("ddl is not supported for driver %q", driver)
+}
+
+// postgresTableDDL reconstructs a table definition from pg_catalog, similar to pg_dump
+func postgresTableDDL(ctx context.Context, db *sql.DB, schema, table string) (string, error) {
+	if schema == "" {
+		schema = "public"
+	}
+
+	name := quoteTableName("postgres", schema, table)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.attname, pg_catalog.format_type(a.atttypid, a.atttypmod), a.attnotnull, pg_catalog.pg_get_expr(d.adbin, d.adrelid)
+		FROM pg_catalog.pg_attribute a
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, name)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer rows.Close()
+
+	var lines []string
+
+	for rows.Next() {
+		var column, dataType string
+		var notNull bool
+		var defaultValue sql.NullString
+
+		if err := rows.Scan(&column, &dataType, &notNull, &defaultValue); err != nil {
+			return "", err
+		}
+
+		line := "    " + quoteIdentifier("postgres", column) + " " + dataType
+
+		if defaultValue.Valid {
+			line += " DEFAULT " + defaultValue.String
+		}
+
+		if notNull {
+			line += " NOT NULL"
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	constraints, err := queryStrings(ctx, db, `
+		SELECT 'CONSTRAINT ' || quote_ident(conname) || ' ' || pg_catalog.pg_get_constraintdef(oid)
+		FROM pg_catalog.pg_constraint
+		WHERE conrelid = $1::regclass
+		ORDER BY CASE contype WHEN 'p' THEN 0 WHEN 'u' THEN 1 WHEN 'c' THEN 2 WHEN 'f' THEN 3 ELSE 4 END, conname`, name)
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range constraints {
+		lines = append(lines, "    "+c)
+	}
+
+	ddl := "CREATE TABLE " + name + " (\n" + strings.Join(lines, ",\n") + "\n);"
+
+	// Indexes not backing a constraint (those are already part of the table definition)
+	indexes, err := queryStrings(ctx, db, `
+		SELECT pg_catalog.pg_get_indexdef(i.indexrelid)
+		FROM pg_catalog.pg_index i
+		WHERE i.indrelid = $1::regclass
+		  AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_constraint c WHERE c.conindid = i.indexrelid)
+		ORDER BY i.indexrelid`, name)
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, index := range indexes {
+		ddl += "\n\n" + index + ";"
+	}
+
+	return ddl, nil
+}
+
+func mysqlTableDDL(ctx context.Context, db *sql.DB, schema, table string) (string, error) {
+	rows, err := db.QueryContext(ctx, "SHOW CREATE TABLE "+quoteTableName("mysql", schema, table))
+
+	if err != nil {
+		return "", err
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+
+	if err != nil {
+		return "", err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+
+		return "", errors.New("table not found")
+	}
+
+	// Tables return (Table, Create Table), views return (View, Create View, ...)
+	values := make([]sql.NullString, len(columns))
+	pointers := make([]any, len(columns))
+
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	if err := rows.Scan(pointers...); err != nil {
+		return "", err
+	}
+
+	if len(values) < 2 {
+		return "", errors.New("unexpected SHOW CREATE TABLE result")
+	}
+
+	return values[1].String + ";", nil
+}
+
+func sqliteTableDDL(ctx context.Context, db *sql.DB, table string) (string, error) {
+	statements, err := queryStrings(ctx, db, `
+		SELECT sql FROM sqlite_master
+		WHERE tbl_name = ? AND sql IS NOT NULL
+		ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'view' THEN 0 ELSE 1 END, name`, table)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(statements) == 0 {
+		return "", errors.New("table not found")
+	}
+
+	return strings.Join(statements, ";\n\n") + ";", nil
+}
+
+// sqlserverTableDDL reconstructs a table definition from INFORMATION_SCHEMA
+func sqlserverTableDDL(ctx context.Context, db *sql.DB, schema, table string) (string, error) {
+	if schema == "" {
+		schema = "dbo"
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT COLUMN_NAME, DATA_TYPE, CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE, IS_NULLABLE, COLUMN_DEFAULT
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_NAME = @p1 AND TABLE_SCHEMA = @p2
+		ORDER BY ORDINAL_POSITION`, table, schema)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer rows.Close()
+
+	var lines []string
+
+	for rows.Next() {
+		var column, dataType, nullable string
+		var length, precision, scale sql.NullInt64
+		var defaultValue sql.NullString
+
+		if err := rows.Scan(&column, &dataType, &length, &precision, &scale, &nullable, &defaultValue); err != nil {
+			return "", err
+		}
+
+		switch strings.ToLower(dataType) {
+		case "char", "varchar", "nchar", "nvarchar", "binary", "varbinary":
+			if length.Int64 < 0 {
+				dataType += "(max)"
+			} else if length.Valid {
+				dataType += fmt.Sprintf("(%d)", length.Int64)
+			}
+
+		case "decimal", "numeric":
+			dataType += fmt.Sprintf("(%d, %d)", precision.Int64, scale.Int64)
+		}
+
+		line := "    " + quoteIdentifier("sqlserver", column) + " " + dataType
+
+		if defaultValue.Valid {
+			line += " DEFAULT " + defaultValue.String
+		}
+
+		if nullable == "NO" {
+			line += " NOT NULL"
+		} else {
+			line += " NULL"
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if len(lines) == 0 {
+		return "", errors.New("table not found")
+	}
+
+	keys, err := queryStrings(ctx, db, `
+		SELECT kcu.COLUMN_NAME
+		FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+		  ON tc.CONSTRAINT_NAME = kcu.CONSTRAINT_NAME AND tc.TABLE_SCHEMA = kcu.TABLE_SCHEMA
+		WHERE tc.CONSTRAINT_TYPE = 'PRIMARY KEY' AND tc.TABLE_NAME = @p1 AND tc.TABLE_SCHEMA = @p2
+		ORDER BY kcu.ORDINAL_POSITION`, table, schema)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(keys) > 0 {
+		for i, key := range keys {
+			keys[i] = quoteIdentifier("sqlserver", key)
+		}
+
+		lines = append(lines, "    PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+	}
+
+	return "CREATE TABLE " + quoteTableName("sqlserver", schema, table) + " (\n" + strings.Join(lines, ",\n") + "\n);", nil
+}
+
+func oracleTableDDL(ctx context.Context, db *sql.DB, schema, table string) (string, error) {
+	var ddl string
+
+	if schema == "" {
+		err := db.QueryRowContext(ctx, "SELECT DBMS_METADATA.GET_DDL('TABLE', :1) FROM DUAL", table).Scan(&ddl)
+		return strings.TrimSpace(ddl), err
+	}
+
+	err := db.QueryRowContext(ctx, "SELECT DBMS_METADATA.GET_DDL('TABLE', :1, :2) FROM DUAL", table, schema).Scan(&ddl)
+	return strings.TrimSpace(ddl), err
+}
+
+// queryStrings runs a query and returns the first column of every row
+func queryStrings(ctx context.Context, db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var result []string
+
+	for rows.Next() {
+		var value sql.NullString
+
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+
+		result = append(result, value.String)
+	}
+
+	return result, rows.Err()
+}
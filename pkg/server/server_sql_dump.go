@@ -0,0 +1,405 @@
+package server
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DumpRequest contains the tables to dump as SQL statements
+type DumpRequest struct {
+	Tables   []DumpTable `json:"tables"`
+	Database string      `json:"database,omitempty"` // Optional: specify which database to query
+
+	DDL bool `json:"ddl,omitempty"` // Optional: also write the CREATE statement of each table
+
+	RowsPerInsert int `json:"rowsPerInsert,omitempty"` // Optional: rows per INSERT statement (default 100)
+}
+
+// DumpTable identifies a table of a dump
+type DumpTable struct {
+	Table  string `json:"table"`
+	Schema string `json:"schema,omitempty"` // Optional: defaults to the driver's default schema
+}
+
+const (
+	// dumpDefaultRowsPerInsert is the number of rows per INSERT unless the request specifies it
+	dumpDefaultRowsPerInsert = 100
+
+	// dumpMaxRowsPerInsert is the limit of SQL Server for rows of a VALUES list
+	dumpMaxRowsPerInsert = 1000
+)
+
+// numericLiteral matches numbers that can be written into a statement as they are
+var numericLiteral = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// POST /sql/{connection}/dump - Stream the data of tables as INSERT statements (and
+// optionally their CREATE statements) in the dialect of the connection
+func (s *Server) handleDump(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req DumpRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if len(req.Tables) == 0 {
+		writeError(w, http.StatusBadRequest, "tables are required")
+		return
+	}
+
+	if req.RowsPerInsert < 0 || req.RowsPerInsert > dumpMaxRowsPerInsert {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("rowsPerInsert must be between 1 and %d", dumpMaxRowsPerInsert))
+		return
+	}
+
+	driver := conn.SQL.Driver
+
+	rowsPerInsert := req.RowsPerInsert
+
+	if rowsPerInsert == 0 {
+		rowsPerInsert = dumpDefaultRowsPerInsert
+	}
+
+	// Oracle has no multi-row VALUES lists
+	if driver == "oracle" {
+		rowsPerInsert = 1
+	}
+
+	queries := make([]string, len(req.Tables))
+
+	for i, t := range req.Tables {
+		if t.Table == "" {
+			writeError(w, http.StatusBadRequest, "table is required")
+			return
+		}
+
+		queries[i] = "SELECT * FROM " + quoteTableName(driver, t.Schema, t.Table)
+
+		if err := checkStatementPolicy(conn.SQL, queries[i]); err != nil {
+			writeErrorFrom(w, http.StatusForbidden, err)
+			return
+		}
+	}
+
+	if err := checkReadOnly(conn.SQL, &SQLRequest{}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mask, err := newColumnMask(conn.SQL.MaskedColumns)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	var q queryer = db
+
+	if readOnlyQueries(conn.SQL, &SQLRequest{}) {
+		tx, err := beginReadOnly(ctx, db, driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	start := time.Now()
+
+	// The DDL is read before any output, so that errors can still be reported as JSON
+	ddls := make([]string, len(req.Tables))
+
+	if req.DDL {
+		for i, t := range req.Tables {
+			ddl, err := tableDDL(ctx, db, driver, t.Schema, t.Table)
+
+			if err != nil {
+				writeErrorFrom(w, http.StatusBadRequest, err)
+				return
+			}
+
+			ddls[i] = strings.TrimRight(strings.TrimSpace(ddl), ";") + ";"
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	started := false
+
+	for i, t := range req.Tables {
+		rows, err := q.QueryContext(ctx, queries[i])
+
+		if err != nil {
+			if !started {
+				writeErrorFrom(w, http.StatusBadRequest, err)
+				return
+			}
+
+			// Headers are already sent; abort so the client sees a truncated file
+			slog.Error("failed to query table for dump", "connection", connID, "table", t.Table, "error", err)
+			return
+		}
+
+		if !started {
+			w.Header().Set("Content-Type", "application/sql")
+			w.Header().Set("Content-Disposition", `attachment; filename="dump.sql"`)
+
+			started = true
+		}
+
+		fmt.Fprintf(out, "-- Table %s\n\n", quoteTableName(driver, t.Schema, t.Table))
+
+		if ddls[i] != "" {
+			fmt.Fprintf(out, "%s\n\n", ddls[i])
+		}
+
+		err = dumpRows(out, rows, driver, quoteTableName(driver, t.Schema, t.Table), rowsPerInsert, mask)
+
+		rows.Close()
+
+		if err != nil {
+			slog.Error("failed to dump table", "connection", connID, "table", t.Table, "error", err)
+			return
+		}
+
+		if err := out.Flush(); err != nil {
+			return
+		}
+
+		http.NewResponseController(w).Flush()
+	}
+
+	s.metrics.observeQuery("export", time.Since(start))
+}
+
+// dumpRows writes the rows of a table as INSERT statements of up to rowsPerInsert rows
+func dumpRows(out *bufio.Writer, rows *sql.Rows, driver, table string, rowsPerInsert int, mask *columnMask) error {
+	columnTypes, err := rows.ColumnTypes()
+
+	if err != nil {
+		return err
+	}
+
+	columns := make([]string, len(columnTypes))
+	typeNames := make([]string, len(columnTypes))
+	masked := make([]bool, len(columnTypes))
+
+	for i, ct := range columnTypes {
+		columns[i] = quoteIdentifier(driver, ct.Name())
+		typeNames[i] = strings.ToUpper(ct.DatabaseTypeName())
+		masked[i] = mask.matches(ct.Name())
+	}
+
+	insert := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES"
+
+	values := make([]any, len(columnTypes))
+	pointers := make([]any, len(columnTypes))
+
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	count := 0
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		if count%rowsPerInsert == 0 {
+			if count > 0 {
+				out.WriteString(";\n")
+			}
+
+			out.WriteString(insert)
+			out.WriteString("\n  (")
+		} else {
+			out.WriteString(",\n  (")
+		}
+
+		for i, val := range values {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+
+			if masked[i] && val != nil {
+				val = maskedValue
+			}
+
+			out.WriteString(sqlLiteral(driver, typeNames[i], val))
+		}
+
+		out.WriteString(")")
+		count++
+	}
+
+	if count > 0 {
+		out.WriteString(";\n\n")
+	}
+
+	return rows.Err()
+}
+
+// sqlLiteral formats a value scanned from a column as a literal of the driver's dialect
+func sqlLiteral(driver, typeName string, val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+
+	case bool:
+		switch driver {
+		case "postgres", "pgx", "mysql", "trino":
+			return strings.ToUpper(strconv.FormatBool(v))
+		}
+
+		if v {
+			return "1"
+		}
+
+		return "0"
+
+	case int64:
+		return strconv.FormatInt(v, 10)
+
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return stringLiteral(driver, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+
+		return strconv.FormatFloat(v, 'g', -1, 64)
+
+	case time.Time:
+		switch driver {
+		case "postgres", "pgx":
+			return "'" + v.Format("2006-01-02 15:04:05.999999-07:00") + "'"
+
+		case "oracle":
+			return "TIMESTAMP '" + v.Format("2006-01-02 15:04:05.999999") + "'"
+		}
+
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+
+	case []byte:
+		if isBinaryType(typeName) {
+			return binaryLiteral(driver, v)
+		}
+
+		return textLiteral(driver, typeName, string(v))
+
+	case string:
+		return textLiteral(driver, typeName, v)
+	}
+
+	return stringLiteral(driver, fmt.Sprint(val))
+}
+
+// textLiteral writes the text of numeric columns (e.g. decimals) as numbers and
+// everything else as string literal
+func textLiteral(driver, typeName, text string) string {
+	if isNumericType(typeName) && numericLiteral.MatchString(text) {
+		return text
+	}
+
+	return stringLiteral(driver, text)
+}
+
+// stringLiteral quotes a string. MySQL treats backslashes as escape characters,
+// SQL Server needs the N prefix to keep non-ASCII characters.
+func stringLiteral(driver, s string) string {
+	switch driver {
+	case "mysql":
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`, "\x00", `\0`).Replace(s) + "'"
+
+	case "sqlserver":
+		return "N'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// binaryLiteral writes bytes as hex literal
+func binaryLiteral(driver string, b []byte) string {
+	data := hex.EncodeToString(b)
+
+	switch driver {
+	case "postgres", "pgx":
+		return `'\x` + data + `'`
+
+	case "sqlserver":
+		return "0x" + data
+
+	case "oracle":
+		return "HEXTORAW('" + data + "')"
+	}
+
+	return "X'" + data + "'"
+}
+
+// isBinaryType reports whether a database type holds raw bytes
+func isBinaryType(typeName string) bool {
+	switch typeName {
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "IMAGE", "RAW", "LONG RAW":
+		return true
+	}
+
+	return false
+}
+
+// isNumericType reports whether a database type holds numbers
+func isNumericType(typeName string) bool {
+	if isDecimalType(typeName) || strings.HasPrefix(typeName, "UNSIGNED ") {
+		return true
+	}
+
+	switch typeName {
+	case "INT", "INT2", "INT4", "INT8", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT",
+		"FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "DOUBLE PRECISION", "REAL", "BINARY_FLOAT", "BINARY_DOUBLE":
+		return true
+	}
+
+	return false
+}
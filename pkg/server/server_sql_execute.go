@@ -1,10 +1,12 @@
 package server
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
+	"time"
 )
 
 func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
@@ -16,7 +18,7 @@ func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -25,41 +27,156 @@ func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if conn.SQL.ReadOnlyTransactions {
+		writeError(w, http.StatusForbidden, "statements cannot be executed on read-only connections")
+		return
+	}
+
 	var req SQLRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := rewritePlaceholders(conn.SQL.Driver, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Modify DSN if a specific database is requested
-	dsn := modifyDSNForDatabase(conn.SQL.Driver, conn.SQL.DSN, req.Database)
+	if err := checkStatementPolicy(conn.SQL, req.Query); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
 
-	db, err := sql.Open(conn.SQL.Driver, dsn)
+	if err := checkConfirmation(conn, &req); err != nil {
+		writeErrorFrom(w, http.StatusPreconditionRequired, err)
+		return
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	params, err := bindParams(req.Params)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "Failed to open database: "+err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := checkScriptPolicy(conn.SQL.Driver, req.OnError, params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
 	defer db.Close()
 
-	if err := db.Ping(); err != nil {
-		writeError(w, http.StatusBadRequest, "Failed to connect to database: "+err.Error())
+	if timeout := s.queryTimeout(conn, &req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	if req.OnError != "" {
+		s.executeScript(ctx, w, connID, conn.SQL.Driver, session, &req)
 		return
 	}
 
-	result, err := db.Exec(req.Query, req.Params...)
+	start := time.Now()
+
+	result, err := session.ExecContext(ctx, req.Query, params...)
+
+	elapsed := time.Since(start)
+
+	s.metrics.observeQuery("execute", elapsed)
+	s.logSlowQuery(connID, &req, elapsed)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
+	if isSchemaChange(conn.SQL.Driver, req.Query) {
+		s.schemas.invalidate(connID)
+	}
+
 	rowsAffected, _ := result.RowsAffected()
 
 	resp := SQLResponse{
 		RowsAffected: rowsAffected,
+
+		OutParams: outValues(params),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// executeScript runs the statements of a script one by one with the request's error policy
+func (s *Server) executeScript(ctx context.Context, w http.ResponseWriter, connID, driver string, session sqlSession, req *SQLRequest) {
+	start := time.Now()
+
+	results, err := runScript(ctx, session, driver, req.OnError, splitStatements(driver, req.Query))
+
+	elapsed := time.Since(start)
+
+	s.metrics.observeQuery("execute", elapsed)
+	s.logSlowQuery(connID, req, elapsed)
+
+	if isSchemaChange(driver, req.Query) {
+		s.schemas.invalidate(connID)
+	}
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := SQLResponse{
+		Statements: results,
+	}
+
+	for _, result := range results {
+		if result.Status == "ok" {
+			resp.RowsAffected += result.RowsAffected
+		}
+
+		if result.Status == "failed" && resp.Error == "" {
+			resp.Error = fmt.Sprintf("statement %d failed: %s", result.Index+1, result.Error)
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,432 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// queryer is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// explainRollback reports whether a query contains an EXPLAIN ANALYZE that must
+// run in a transaction that is rolled back afterwards. The database actually
+// executes analyzed statements, so on PostgreSQL and MySQL every one of them is
+// rolled back, as even queries can modify data (e.g. through data-modifying CTEs
+// or functions with side effects). An error is returned if the driver cannot
+// undo the changes of a statement that modifies data.
+func explainRollback(driver, query string) (bool, error) {
+	rollback := false
+
+	for _, stmt := range splitStatements(driver, query) {
+		keyword, analyze := explainStatement(driver, stmt)
+
+		if !analyze {
+			continue
+		}
+
+		switch driver {
+		case "postgres", "pgx":
+			rollback = true
+			continue
+
+		case "mysql":
+			// DDL statements commit implicitly on MySQL
+			switch keyword {
+			case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME":
+				return false, fmt.Errorf("EXPLAIN ANALYZE of %s statements cannot be rolled back on MySQL", keyword)
+			}
+
+			rollback = true
+			continue
+		}
+
+		switch keyword {
+		case "SELECT", "VALUES", "TABLE", "SHOW":
+			continue
+		}
+
+		return false, fmt.Errorf("EXPLAIN ANALYZE of %s statements is not supported for driver %s, as the changes cannot be rolled back", keyword, driver)
+	}
+
+	return rollback, nil
+}
+
+// explainStatement returns the type of the statement explained by an EXPLAIN
+// statement and whether it is analyzed, i.e. executed. Both PostgreSQL option
+// lists ("EXPLAIN (ANALYZE, BUFFERS) ...") and MySQL modifiers ("EXPLAIN
+// ANALYZE FORMAT=TREE ...") are recognized.
+func explainStatement(driver, stmt string) (string, bool) {
+	tokens := topLevelTokens(driver, stmt)
+
+	if len(tokens) < 2 || tokens[0].word != "EXPLAIN" {
+		return "", false
+	}
+
+	options := strings.ToUpper(stmt[tokens[0].end:tokens[1].start])
+	analyze := strings.Contains(options, "ANALYZE") || strings.Contains(options, "ANALYSE")
+
+	for _, t := range tokens[1:] {
+		switch t.word {
+		case "ANALYZE", "ANALYSE":
+			analyze = true
+
+		case "VERBOSE", "FORMAT", "TREE", "JSON", "TRADITIONAL":
+
+		default:
+			return statementType(driver, stmt[t.start:]), analyze
+		}
+	}
+
+	return "", false
+}
+
+// ExplainRequest contains a statement to explain
+type ExplainRequest struct {
+	SQLRequest
+
+	// Analyze executes the statement to report actual times and row counts.
+	// Writing statements run in a transaction that is rolled back.
+	Analyze bool `json:"analyze,omitempty"`
+}
+
+// ExplainResponse contains a query plan as a tree of nodes
+type ExplainResponse struct {
+	Plan PlanNode `json:"plan"`
+
+	PlanningTimeMs  *float64 `json:"planningTimeMs,omitempty"`
+	ExecutionTimeMs *float64 `json:"executionTimeMs,omitempty"`
+}
+
+// PlanNode is a node of a query plan. Costs are in the planner's arbitrary
+// units, times in milliseconds; actual values are only set for analyzed plans.
+type PlanNode struct {
+	NodeType string `json:"nodeType"`
+
+	Relation string `json:"relation,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+	Index    string `json:"index,omitempty"`
+
+	StartupCost float64 `json:"startupCost"`
+	TotalCost   float64 `json:"totalCost"`
+	PlanRows    float64 `json:"planRows"`
+	PlanWidth   float64 `json:"planWidth"`
+
+	ActualStartupTime *float64 `json:"actualStartupTime,omitempty"` // per loop
+	ActualTotalTime   *float64 `json:"actualTotalTime,omitempty"`   // per loop
+	ActualRows        *float64 `json:"actualRows,omitempty"`        // per loop
+	ActualLoops       *float64 `json:"actualLoops,omitempty"`
+
+	// SelfTime is the time spent in this node over all loops, excluding its children
+	SelfTime *float64 `json:"selfTime,omitempty"`
+
+	// Details holds all other properties reported for the node (e.g. "Filter", "Join Type")
+	Details map[string]any `json:"details,omitempty"`
+
+	Children []PlanNode `json:"children,omitempty"`
+}
+
+// POST /sql/{connection}/explain - Explain a statement and return its plan as a tree (PostgreSQL)
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	if conn.SQL.Driver != "postgres" && conn.SQL.Driver != "pgx" {
+		writeError(w, http.StatusBadRequest, "plan trees are only supported for PostgreSQL connections")
+		return
+	}
+
+	var req ExplainRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	statements := splitStatements(conn.SQL.Driver, req.Query)
+
+	if len(statements) != 1 {
+		writeError(w, http.StatusBadRequest, "explain requires a single statement")
+		return
+	}
+
+	if statementType(conn.SQL.Driver, statements[0]) == "EXPLAIN" {
+		writeError(w, http.StatusBadRequest, "statement must not start with EXPLAIN")
+		return
+	}
+
+	req.Query = statements[0]
+
+	if err := rewritePlaceholders(conn.SQL.Driver, &req.SQLRequest); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := checkStatementPolicy(conn.SQL, req.Query); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkReadOnly(conn.SQL, &req.SQLRequest); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params, err := bindParams(req.Params)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := "EXPLAIN (FORMAT JSON) " + req.Query
+
+	if req.Analyze {
+		query = "EXPLAIN (ANALYZE, FORMAT JSON) " + req.Query
+	}
+
+	rollback, err := explainRollback(conn.SQL.Driver, query)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &req.SQLRequest); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	var q queryer = session
+
+	switch {
+	case readOnlyQueries(conn.SQL, &req.SQLRequest):
+		// EXPLAIN ANALYZE executes the statement, which must not write either
+		tx, err := beginReadOnly(ctx, session, conn.SQL.Driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+
+	case rollback:
+		tx, err := session.BeginTx(ctx, nil)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	rows, err := q.QueryContext(ctx, query, params...)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer rows.Close()
+
+	var data []byte
+
+	if rows.Next() {
+		if err := rows.Scan(&data); err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := parsePostgresPlan(data)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parsePostgresPlan converts the output of EXPLAIN (FORMAT JSON) into a plan tree
+func parsePostgresPlan(data []byte) (*ExplainResponse, error) {
+	var result []map[string]any
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid plan: %w", err)
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("invalid plan: no plan returned")
+	}
+
+	plan, ok := result[0]["Plan"].(map[string]any)
+
+	if !ok {
+		return nil, errors.New("invalid plan: missing root node")
+	}
+
+	resp := &ExplainResponse{
+		Plan: parsePlanNode(plan),
+
+		PlanningTimeMs:  planFloat(result[0], "Planning Time"),
+		ExecutionTimeMs: planFloat(result[0], "Execution Time"),
+	}
+
+	return resp, nil
+}
+
+func parsePlanNode(m map[string]any) PlanNode {
+	node := PlanNode{
+		ActualStartupTime: planFloat(m, "Actual Startup Time"),
+		ActualTotalTime:   planFloat(m, "Actual Total Time"),
+		ActualRows:        planFloat(m, "Actual Rows"),
+		ActualLoops:       planFloat(m, "Actual Loops"),
+	}
+
+	node.NodeType, _ = m["Node Type"].(string)
+	node.Relation, _ = m["Relation Name"].(string)
+	node.Alias, _ = m["Alias"].(string)
+	node.Index, _ = m["Index Name"].(string)
+
+	node.StartupCost, _ = m["Startup Cost"].(float64)
+	node.TotalCost, _ = m["Total Cost"].(float64)
+	node.PlanRows, _ = m["Plan Rows"].(float64)
+	node.PlanWidth, _ = m["Plan Width"].(float64)
+
+	if children, ok := m["Plans"].([]any); ok {
+		for _, child := range children {
+			if c, ok := child.(map[string]any); ok {
+				node.Children = append(node.Children, parsePlanNode(c))
+			}
+		}
+	}
+
+	for key, value := range m {
+		switch key {
+		case "Node Type", "Relation Name", "Alias", "Index Name",
+			"Startup Cost", "Total Cost", "Plan Rows", "Plan Width",
+			"Actual Startup Time", "Actual Total Time", "Actual Rows", "Actual Loops",
+			"Plans":
+			continue
+		}
+
+		if node.Details == nil {
+			node.Details = make(map[string]any)
+		}
+
+		node.Details[key] = value
+	}
+
+	// the time of a node includes its children and is reported per loop
+	if total := nodeTime(node); total != nil {
+		self := *total
+
+		for _, child := range node.Children {
+			if t := nodeTime(child); t != nil {
+				self -= *t
+			}
+		}
+
+		self = max(self, 0)
+		node.SelfTime = &self
+	}
+
+	return node
+}
+
+// nodeTime returns the total time of a node over all loops
+func nodeTime(node PlanNode) *float64 {
+	if node.ActualTotalTime == nil {
+		return nil
+	}
+
+	total := *node.ActualTotalTime
+
+	if node.ActualLoops != nil {
+		total *= *node.ActualLoops
+	}
+
+	return &total
+}
+
+func planFloat(m map[string]any, key string) *float64 {
+	if v, ok := m[key].(float64); ok {
+		return &v
+	}
+
+	return nil
+}
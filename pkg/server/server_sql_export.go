@@ -0,0 +1,706 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// exportRowGroupSize bounds the number of rows buffered in memory per Parquet row group
+const exportRowGroupSize = 100_000
+
+// parquetKind is the Parquet column type a SQL column is exported as
+type parquetKind int
+
+const (
+	parquetString parquetKind = iota
+	parquetBytes
+	parquetBool
+	parquetInt
+	parquetFloat
+	parquetTimestamp
+)
+
+// POST /sql/{connection}/query/export?format=parquet - Stream the result of a query as a file
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+
+	if format != "parquet" {
+		writeError(w, http.StatusBadRequest, "format must be \"parquet\"")
+		return
+	}
+
+	var req SQLRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := rewritePlaceholders(conn.SQL.Driver, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := checkStatementPolicy(conn.SQL, req.Query); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkConfirmation(conn, &req); err != nil {
+		writeErrorFrom(w, http.StatusPreconditionRequired, err)
+		return
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkReadOnly(conn.SQL, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params, err := bindParams(req.Params)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	mask, err := newColumnMask(conn.SQL.MaskedColumns)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	var q queryer = session
+
+	if readOnlyQueries(conn.SQL, &req) {
+		tx, err := beginReadOnly(ctx, session, conn.SQL.Driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	start := time.Now()
+
+	rows, err := q.QueryContext(ctx, req.Query, params...)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	names := make([]string, len(columnTypes))
+
+	for i, ct := range columnTypes {
+		names[i] = ct.Name()
+
+		// Parquet fields must be named
+		if names[i] == "" {
+			names[i] = "column_" + strconv.Itoa(i+1)
+		}
+	}
+
+	names = uniqueColumnNames(names)
+
+	kinds := make([]parquetKind, len(columnTypes))
+	fields := make(parquet.Group, len(columnTypes))
+
+	masked := make([]bool, len(columnTypes))
+
+	for i, ct := range columnTypes {
+		kinds[i] = parquetKindOf(ct)
+
+		if mask.matches(ct.Name()) {
+			masked[i] = true
+			kinds[i] = parquetString
+		}
+
+		fields[names[i]] = parquet.Optional(parquetNode(kinds[i]))
+	}
+
+	schema := parquet.NewSchema("result", fields)
+
+	// Group fields are ordered by name, so map result columns to their leaf columns
+	indexes := make([]int, len(names))
+
+	for i, name := range names {
+		leaf, _ := schema.Lookup(name)
+		indexes[i] = leaf.ColumnIndex
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+	w.Header().Set("Content-Disposition", `attachment; filename="export.parquet"`)
+
+	writer := parquet.NewWriter(w, schema,
+		parquet.Compression(&parquet.Snappy),
+		parquet.MaxRowsPerRowGroup(exportRowGroupSize),
+	)
+
+	values := make([]any, len(columnTypes))
+	pointers := make([]any, len(columnTypes))
+
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			// Headers are already sent; abort so the client sees a truncated file
+			slog.Error("failed to scan row for export", "connection", connID, "error", err)
+			return
+		}
+
+		row := make(parquet.Row, len(values))
+
+		for i, val := range values {
+			if masked[i] && val != nil {
+				val = maskedValue
+			}
+
+			v, err := parquetValue(kinds[i], val)
+
+			if err != nil {
+				slog.Error("failed to convert value for export", "connection", connID, "column", names[i], "error", err)
+				return
+			}
+
+			if v.IsNull() {
+				row[indexes[i]] = v.Level(0, 0, indexes[i])
+			} else {
+				row[indexes[i]] = v.Level(0, 1, indexes[i])
+			}
+		}
+
+		if _, err := writer.WriteRows([]parquet.Row{row}); err != nil {
+			slog.Error("failed to write export row", "connection", connID, "error", err)
+			return
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("failed to read rows for export", "connection", connID, "error", err)
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		slog.Error("failed to finish export", "connection", connID, "error", err)
+		return
+	}
+
+	elapsed := time.Since(start)
+
+	s.metrics.observeQuery("export", elapsed)
+	s.logSlowQuery(connID, &req, elapsed)
+}
+
+// parquetKindOf derives the Parquet type of a result column from its database and scan types.
+// Exact numerics are exported as strings so that no precision is lost.
+func parquetKindOf(ct *sql.ColumnType) parquetKind {
+	typeName := strings.ToUpper(ct.DatabaseTypeName())
+
+	if isDecimalType(typeName) {
+		return parquetString
+	}
+
+	if isBinaryType(typeName) {
+		return parquetBytes
+	}
+
+	switch typeName {
+	case "DATE", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET", "TIMESTAMP", "TIMESTAMPTZ":
+		return parquetTimestamp
+	}
+
+	t := ct.ScanType()
+
+	if t == nil {
+		return parquetString
+	}
+
+	switch t {
+	case reflect.TypeFor[sql.NullBool]():
+		return parquetBool
+	case reflect.TypeFor[sql.NullInt64](), reflect.TypeFor[sql.NullInt32](), reflect.TypeFor[sql.NullInt16](), reflect.TypeFor[sql.NullByte]():
+		return parquetInt
+	case reflect.TypeFor[sql.NullFloat64]():
+		return parquetFloat
+	case reflect.TypeFor[sql.NullTime](), reflect.TypeFor[time.Time]():
+		return parquetTimestamp
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return parquetBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return parquetInt
+	case reflect.Float32, reflect.Float64:
+		return parquetFloat
+	}
+
+	return parquetString
+}
+
+// parquetNode returns the Parquet leaf node for a column kind
+func parquetNode(kind parquetKind) parquet.Node {
+	switch kind {
+	case parquetBytes:
+		return parquet.Leaf(parquet.ByteArrayType)
+	case parquetBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case parquetInt:
+		return parquet.Int(64)
+	case parquetFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case parquetTimestamp:
+		return parquet.Timestamp(parquet.Microsecond)
+	default:
+		return parquet.String()
+	}
+}
+
+// parquetValue converts a scanned value to a Parquet value of the given kind
+func parquetValue(kind parquetKind, val any) (parquet.Value, error) {
+	if val == nil {
+		return parquet.Value{}, nil
+	}
+
+	text := func() string {
+		switch v := val.(type) {
+		case []byte:
+			return string(v)
+		case string:
+			return v
+		case time.Time:
+			return v.Format(time.RFC3339Nano)
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+
+	switch kind {
+	case parquetBytes:
+		if b, ok := val.([]byte); ok {
+			return parquet.ByteArrayValue(b), nil
+		}
+
+		return parquet.ByteArrayValue([]byte(text())), nil
+
+	case parquetBool:
+		if b, ok := val.(bool); ok {
+			return parquet.BooleanValue(b), nil
+		}
+
+		if n, ok := val.(int64); ok {
+			return parquet.BooleanValue(n != 0), nil
+		}
+
+		b, err := strconv.ParseBool(text())
+		return parquet.BooleanValue(b), err
+
+	case parquetInt:
+		rv := reflect.ValueOf(val)
+
+		switch {
+		case rv.CanInt():
+			return parquet.Int64Value(rv.Int()), nil
+		case rv.CanUint():
+			return parquet.Int64Value(int64(rv.Uint())), nil
+		}
+
+		n, err := strconv.ParseInt(text(), 10, 64)
+		return parquet.Int64Value(n), err
+
+	case parquetFloat:
+		rv := reflect.ValueOf(val)
+
+		switch {
+		case rv.CanFloat():
+			return parquet.DoubleValue(rv.Float()), nil
+		case rv.CanInt():
+			return parquet.DoubleValue(float64(rv.Int())), nil
+		}
+
+		f, err := strconv.ParseFloat(text(), 64)
+		return parquet.DoubleValue(f), err
+
+	case parquetTimestamp:
+		if t, ok := val.(time.Time); ok {
+			return parquet.Int64Value(t.UnixMicro()), nil
+		}
+
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+			if t, err := time.Parse(layout, text()); err == nil {
+				return parquet.Int64Value(t.UnixMicro()), nil
+			}
+		}
+
+		return parquet.Value{}, fmt.Errorf("invalid timestamp %q", text())
+
+	default:
+		return parquet.ByteArrayValue([]byte(text())), nil
+	}
+}
+
+// TableExportRequest contains parameters for exporting a whole table
+type TableExportRequest struct {
+	Table    string `json:"table"`
+	Schema   string `json:"schema,omitempty"`   // Optional: defaults to the driver's default schema
+	Database string `json:"database,omitempty"` // Optional: specify which database to query
+
+	// Key is a unique, sortable column (usually the primary key) the table is scrolled by
+	Key string `json:"key"`
+
+	BatchSize int `json:"batchSize,omitempty"` // Optional: rows fetched per query (default 5000)
+
+	// Optional: CSV field written for NULL values, e.g. "\\N" (as Postgres COPY) or "NULL".
+	// By default NULL and empty strings both become empty fields.
+	NullValue string `json:"nullValue,omitempty"`
+}
+
+// POST /sql/{connection}/table/export?format=ndjson|csv - Stream a whole table using keyset pagination.
+// Every batch continues after the last key seen (WHERE key > last ORDER BY key), which stays
+// fast on deep pages unlike OFFSET.
+func (s *Server) handleTableExport(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+
+	if format != "ndjson" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "format must be \"ndjson\" or \"csv\"")
+		return
+	}
+
+	var req TableExportRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if req.Table == "" || req.Key == "" {
+		writeError(w, http.StatusBadRequest, "table and key are required")
+		return
+	}
+
+	batchSize := req.BatchSize
+
+	if batchSize <= 0 {
+		batchSize = 5000
+	}
+
+	driver := conn.SQL.Driver
+
+	firstQuery := keysetQuery(driver, req.Schema, req.Table, req.Key, batchSize, false)
+
+	if err := checkStatementPolicy(conn.SQL, firstQuery); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	mask, err := newColumnMask(conn.SQL.MaskedColumns)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Batches continue after the last key, which must be readable
+	if mask.matches(req.Key) {
+		writeError(w, http.StatusForbidden, "key column is masked: "+req.Key)
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	start := time.Now()
+
+	opts := resultOptions{
+		DecimalsAsStrings: true,
+
+		Mask: mask,
+	}
+
+	// The first batch is read before any output, so that errors can still be reported as JSON
+	columns, batch, err := readKeysetBatch(ctx, db, firstQuery, nil, opts)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	keyIndex := slices.Index(columns, req.Key)
+
+	if keyIndex < 0 {
+		writeError(w, http.StatusBadRequest, "key column not found: "+req.Key)
+		return
+	}
+
+	var write func(row []any) error
+	var flush func() error
+
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+
+		write = func(row []any) error {
+			record := make([]string, len(row))
+
+			for i, val := range row {
+				if val == nil {
+					record[i] = req.NullValue
+					continue
+				}
+
+				record[i] = csvValue(val)
+			}
+
+			return cw.Write(record)
+		}
+
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", req.Table+".csv"))
+
+		if err := cw.Write(columns); err != nil {
+			return
+		}
+
+	default:
+		enc := json.NewEncoder(w)
+
+		write = func(row []any) error {
+			obj := make(map[string]any, len(row))
+
+			for i, val := range row {
+				obj[columns[i]] = val
+			}
+
+			return enc.Encode(obj)
+		}
+
+		flush = func() error {
+			return nil
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", req.Table+".ndjson"))
+	}
+
+	rc := http.NewResponseController(w)
+
+	for {
+		for _, row := range batch {
+			if err := write(row); err != nil {
+				return
+			}
+		}
+
+		if err := flush(); err != nil {
+			return
+		}
+
+		rc.Flush()
+
+		if len(batch) < batchSize {
+			break
+		}
+
+		last := batch[len(batch)-1][keyIndex]
+
+		query := keysetQuery(driver, req.Schema, req.Table, req.Key, batchSize, true)
+
+		if _, batch, err = readKeysetBatch(ctx, db, query, []any{last}, opts); err != nil {
+			// Headers are already sent; abort so the client sees a truncated file
+			slog.Error("failed to read export batch", "connection", connID, "table", req.Table, "error", err)
+			return
+		}
+	}
+
+	s.metrics.observeQuery("export", time.Since(start))
+}
+
+// readKeysetBatch runs one batch query of a keyset export
+func readKeysetBatch(ctx context.Context, db *sql.DB, query string, args []any, opts resultOptions) ([]string, [][]any, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	columns, data, _, err := rowsToArrays(rows, opts)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return uniqueColumnNames(columns), data, nil
+}
+
+// keysetQuery builds the statement reading the next batch of rows ordered by key,
+// continuing after the key bound to the first parameter
+func keysetQuery(driver, schema, table, key string, limit int, after bool) string {
+	name := quoteTableName(driver, schema, table)
+	column := quoteIdentifier(driver, key)
+	n := strconv.Itoa(limit)
+
+	where := ""
+
+	if after {
+		where = " WHERE " + column + " > " + placeholder(driver, 1)
+	}
+
+	switch driver {
+	case "sqlserver":
+		return "SELECT TOP " + n + " * FROM " + name + where + " ORDER BY " + column
+
+	case "oracle":
+		return "SELECT * FROM " + name + where + " ORDER BY " + column + " FETCH FIRST " + n + " ROWS ONLY"
+
+	default:
+		return "SELECT * FROM " + name + where + " ORDER BY " + column + " LIMIT " + n
+	}
+}
+
+// csvValue formats a result value as a CSV field
+func csvValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.RawMessage:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any, map[string]any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
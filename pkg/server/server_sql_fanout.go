@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// FanoutRequest contains a query to run against several connections
+type FanoutRequest struct {
+	SQLRequest
+
+	Connections []string `json:"connections"`
+}
+
+// FanoutResult contains the result of a fan-out query on a single connection
+type FanoutResult struct {
+	Connection string `json:"connection"`
+
+	Columns []string `json:"columns,omitempty"`
+	Rows    any      `json:"rows,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	ElapsedMs int64 `json:"elapsedMs"`
+}
+
+const (
+	// fanoutConcurrency bounds the number of connections queried at the same time
+	fanoutConcurrency = 8
+
+	// fanoutDefaultLimit caps the rows returned per connection unless a limit is given
+	fanoutDefaultLimit = 1000
+)
+
+// POST /sql/fanout - Run a query against several connections concurrently.
+// Results are returned in the order of the requested connections.
+func (s *Server) handleFanout(w http.ResponseWriter, r *http.Request) {
+	var req FanoutRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if len(req.Connections) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one connection is required")
+		return
+	}
+
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	if req.Format != "" && req.Format != "objects" && req.Format != "arrays" {
+		writeError(w, http.StatusBadRequest, "format must be \"objects\" or \"arrays\"")
+		return
+	}
+
+	if req.Limit < 0 {
+		writeError(w, http.StatusBadRequest, "limit must not be negative")
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = fanoutDefaultLimit
+	}
+
+	ctx := r.Context()
+
+	results := make([]FanoutResult, len(req.Connections))
+
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, fanoutConcurrency)
+
+	for i, connID := range req.Connections {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// every connection gets its own copy, as placeholders may be rewritten
+			results[i] = s.fanoutQuery(ctx, connID, req.SQLRequest)
+		}()
+	}
+
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// fanoutQuery runs a query against one connection, applying the same policy
+// checks as a regular query, and reports failures in the result
+func (s *Server) fanoutQuery(ctx context.Context, connID string, req SQLRequest) FanoutResult {
+	result := FanoutResult{
+		Connection: connID,
+	}
+
+	fail := func(message string) FanoutResult {
+		result.Error = message
+		return result
+	}
+
+	conn, err := s.getConnection(connID)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fail("connection not found")
+		}
+
+		return fail(err.Error())
+	}
+
+	if conn.SQL == nil {
+		return fail("connection is not a SQL connection")
+	}
+
+	// statements are counted in the dialect of each connection
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		return fail(err.Error())
+	}
+
+	if err := rewritePlaceholders(conn.SQL.Driver, &req); err != nil {
+		return fail(err.Error())
+	}
+
+	if err := checkStatementPolicy(conn.SQL, req.Query); err != nil {
+		return fail(err.Error())
+	}
+
+	if err := checkConfirmation(conn, &req); err != nil {
+		return fail(err.Error())
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		return fail(err.Error())
+	}
+
+	if err := checkReadOnly(conn.SQL, &req); err != nil {
+		return fail(err.Error())
+	}
+
+	params, err := bindParams(req.Params)
+
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	mask, err := newColumnMask(conn.SQL.MaskedColumns)
+
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			return fail(err.Error())
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	var q queryer = session
+
+	if readOnlyQueries(conn.SQL, &req) {
+		tx, err := beginReadOnly(ctx, session, conn.SQL.Driver)
+
+		if err != nil {
+			return fail(err.Error())
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	start := time.Now()
+
+	rows, err := q.QueryContext(ctx, req.Query, params...)
+
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	defer rows.Close()
+
+	sets, err := resultSetsToJSON(rows, resultOptions{
+		Format: req.Format,
+		Limit:  req.Limit,
+
+		DecimalsAsStrings: req.DecimalsAsStrings,
+
+		Mask: mask,
+	})
+
+	elapsed := time.Since(start)
+
+	s.metrics.observeQuery("fanout", elapsed)
+	s.logSlowQuery(connID, &req, elapsed)
+
+	result.ElapsedMs = elapsed.Milliseconds()
+
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	result.Columns = sets[0].Columns
+	result.Rows = sets[0].Rows
+	result.HasMore = sets[0].HasMore
+
+	return result
+}
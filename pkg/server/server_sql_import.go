@@ -0,0 +1,421 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/lib/pq"
+)
+
+// ImportResponse reports the outcome of a NDJSON import
+type ImportResponse struct {
+	Inserted int64    `json:"inserted"`
+	Columns  []string `json:"columns"`
+
+	Errors []ImportBatchError `json:"errors,omitempty"`
+}
+
+// ImportBatchError describes a batch that could not be inserted
+type ImportBatchError struct {
+	Batch     int    `json:"batch"`
+	FirstLine int    `json:"firstLine"`
+	LastLine  int    `json:"lastLine"`
+	Error     string `json:"error"`
+}
+
+// importBatch holds the rows of one batch and the input lines they came from
+type importBatch struct {
+	rows      [][]any
+	firstLine int
+	lastLine  int
+	err       error
+}
+
+// POST /sql/{connection}/import?table=X - Insert NDJSON rows into a table.
+// Columns are inferred from the first object; every batch is inserted in its
+// own transaction, so a failing batch is rolled back and reported while the
+// remaining batches are still imported. Postgres connections load via COPY.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	query := r.URL.Query()
+
+	table := query.Get("table")
+
+	if table == "" {
+		writeError(w, http.StatusBadRequest, "table is required")
+		return
+	}
+
+	batchSize := 500
+
+	if value := query.Get("batchSize"); value != "" {
+		n, err := strconv.Atoi(value)
+
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "batchSize must be a positive number")
+			return
+		}
+
+		batchSize = n
+	}
+
+	if err := checkStatementPolicy(conn.SQL, "INSERT"); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if conn.SQL.ReadOnlyTransactions {
+		writeError(w, http.StatusForbidden, "rows cannot be imported on read-only connections")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxUploadSize)
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, query.Get("database"))
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	driver := conn.SQL.Driver
+	schema := query.Get("schema")
+
+	var resp ImportResponse
+
+	batches := 0
+
+	err = readImportBatches(r.Body, batchSize, func(columns []string, batch importBatch) error {
+		resp.Columns = columns
+		batches++
+
+		if batch.err == nil {
+			batch.err = insertBatch(ctx, db, driver, schema, table, columns, batch.rows)
+		}
+
+		if batch.err != nil {
+			resp.Errors = append(resp.Errors, ImportBatchError{
+				Batch:     batches,
+				FirstLine: batch.firstLine,
+				LastLine:  batch.lastLine,
+				Error:     batch.err.Error(),
+			})
+
+			return nil
+		}
+
+		resp.Inserted += int64(len(batch.rows))
+		return ctx.Err()
+	})
+
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+
+		if errors.As(err, &maxBytesErr) || batches == 0 {
+			writeDecodeError(w, err, err.Error())
+			return
+		}
+
+		// Earlier batches are already processed, so report the failure alongside them
+		resp.Errors = append(resp.Errors, ImportBatchError{
+			Batch: batches + 1,
+			Error: err.Error(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// readImportBatches parses NDJSON rows and passes them to fn in batches of
+// batchSize. Columns are taken from the keys of the first object. A row with
+// unknown keys fails its batch, missing keys are inserted as NULL.
+func readImportBatches(body io.Reader, batchSize int, fn func(columns []string, batch importBatch) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+
+	var columns []string
+	var index map[string]int
+
+	var batch importBatch
+
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		data := bytes.TrimSpace(scanner.Bytes())
+
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		var object map[string]any
+
+		if err := decoder.Decode(&object); err != nil || object == nil {
+			return fmt.Errorf("line %d: expected a JSON object", line)
+		}
+
+		if columns == nil {
+			for key := range object {
+				columns = append(columns, key)
+			}
+
+			if len(columns) == 0 {
+				return fmt.Errorf("line %d: the first object has no columns", line)
+			}
+
+			slices.Sort(columns)
+
+			index = make(map[string]int, len(columns))
+
+			for i, col := range columns {
+				index[col] = i
+			}
+		}
+
+		if len(batch.rows) == 0 {
+			batch.firstLine = line
+		}
+
+		batch.lastLine = line
+
+		row := make([]any, len(columns))
+
+		for key, value := range object {
+			i, ok := index[key]
+
+			if !ok {
+				if batch.err == nil {
+					batch.err = fmt.Errorf("line %d: unknown column %q", line, key)
+				}
+
+				continue
+			}
+
+			value, err := importValue(value)
+
+			if err != nil && batch.err == nil {
+				batch.err = fmt.Errorf("line %d: column %q: %w", line, key, err)
+			}
+
+			row[i] = value
+		}
+
+		batch.rows = append(batch.rows, row)
+
+		if len(batch.rows) >= batchSize {
+			if err := fn(columns, batch); err != nil {
+				return err
+			}
+
+			batch = importBatch{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(batch.rows) > 0 {
+		return fn(columns, batch)
+	}
+
+	if columns == nil {
+		return errors.New("no rows to import")
+	}
+
+	return nil
+}
+
+// importValue converts a decoded JSON value into a driver value. Numbers are
+// bound as integers where possible, {"$binary": "<base64>"} as bytes and other
+// objects or arrays as their JSON text.
+func importValue(value any) (any, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return numberValue(v)
+
+	case map[string]any:
+		if params, err := bindParams([]any{v}); err != nil {
+			return nil, err
+		} else if data, ok := params[0].([]byte); ok {
+			return data, nil
+		}
+
+		data, err := json.Marshal(v)
+		return string(data), err
+
+	case []any:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+
+	return value, nil
+}
+
+// insertBatch inserts rows into a table within a single transaction, using
+// COPY on Postgres and multi-row INSERT statements where the driver supports them
+func insertBatch(ctx context.Context, db *sql.DB, driver, schema, table string, columns []string, rows [][]any) error {
+	if driver == "pgx" {
+		return copyRowsPgx(ctx, db, schema, table, columns, rows)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if driver == "postgres" {
+		if err := copyRows(ctx, tx, schema, table, columns, rows); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	quoted := make([]string, len(columns))
+
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(driver, col)
+	}
+
+	prefix := "INSERT INTO " + quoteTableName(driver, schema, table) + " (" + strings.Join(quoted, ", ") + ") VALUES "
+
+	// stay below the parameter limits of the drivers (e.g. 2100 on SQL Server)
+	perStatement := max(1, 2000/len(columns))
+
+	if driver == "oracle" {
+		perStatement = 1
+	}
+
+	for len(rows) > 0 {
+		chunk := rows[:min(perStatement, len(rows))]
+		rows = rows[len(chunk):]
+
+		var query strings.Builder
+		var args []any
+
+		query.WriteString(prefix)
+
+		for i, row := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+
+			query.WriteString("(")
+
+			for j, value := range row {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+
+				args = append(args, value)
+				query.WriteString(placeholder(driver, len(args)))
+			}
+
+			query.WriteString(")")
+		}
+
+		if _, err := tx.ExecContext(ctx, query.String(), args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// copyRowsPgx streams rows into a Postgres table using the COPY protocol of pgx.
+// A COPY is a single statement, so a failing batch leaves no rows behind.
+func copyRowsPgx(ctx context.Context, db *sql.DB, schema, table string, columns []string, rows [][]any) error {
+	conn, err := db.Conn(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	name := pgx.Identifier{table}
+
+	if schema != "" {
+		name = pgx.Identifier{schema, table}
+	}
+
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+
+		if !ok {
+			return fmt.Errorf("unexpected pgx connection type %T", driverConn)
+		}
+
+		_, err := c.Conn().CopyFrom(ctx, name, columns, pgx.CopyFromRows(rows))
+		return err
+	})
+}
+
+// copyRows streams rows into a Postgres table using COPY FROM STDIN
+func copyRows(ctx context.Context, tx *sql.Tx, schema, table string, columns []string, rows [][]any) error {
+	query := pq.CopyIn(table, columns...)
+
+	if schema != "" {
+		query = pq.CopyInSchema(schema, table, columns...)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+
+	// an empty exec flushes the buffered rows and completes the COPY
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
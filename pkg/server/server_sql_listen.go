@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/adrianliechti/granite/pkg/tunnel"
+
+	"github.com/lib/pq"
+)
+
+// Notification is a Postgres NOTIFY event
+type Notification struct {
+	Channel string `json:"channel"`
+	Payload string `json:"payload"`
+	PID     int    `json:"pid"`
+}
+
+// GET /sql/{connection}/listen?channel=foo - Stream Postgres notifications as server-sent events
+func (s *Server) handleListen(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	if conn.SQL.Driver != "postgres" && conn.SQL.Driver != "pgx" {
+		writeError(w, http.StatusBadRequest, "LISTEN is only supported for postgres connections")
+		return
+	}
+
+	channel := r.URL.Query().Get("channel")
+
+	if channel == "" {
+		writeError(w, http.StatusBadRequest, "channel is required")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	dsn, err := effectiveDSN(conn.SQL, r.URL.Query().Get("database"))
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Bound only the connection attempt, the stream runs until the client leaves
+	connectCtx := ctx
+
+	if timeout := s.connectTimeout(conn); timeout > 0 {
+		var cancel context.CancelFunc
+		connectCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if conn.SQL.SSH != nil {
+		var t *tunnel.Tunnel
+
+		if dsn, t, err = openTunnel(connectCtx, conn.SQL, dsn); err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer t.Close()
+	}
+
+	// Listen waits for a connection indefinitely, so the outcome of the first
+	// attempt is awaited before
+	connected := make(chan error, 1)
+
+	// The listener holds its own dedicated connection, separate from query connections
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if event == pq.ListenerEventConnected || event == pq.ListenerEventConnectionAttemptFailed {
+			select {
+			case connected <- err:
+			default:
+			}
+		}
+	})
+	defer listener.Close()
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, fmt.Errorf("failed to connect to database: %w", err))
+			return
+		}
+
+	case <-connectCtx.Done():
+		writeErrorFrom(w, http.StatusBadRequest, fmt.Errorf("failed to connect to database: %w", connectCtx.Err()))
+		return
+	}
+
+	if err := listener.Listen(channel); err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to listen: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case n := <-listener.Notify:
+			// nil signals a re-established connection; notifications may have been missed
+			if n == nil {
+				fmt.Fprint(w, "event: reconnect\ndata: {}\n\n")
+				flusher.Flush()
+				continue
+			}
+
+			data, _ := json.Marshal(Notification{
+				Channel: n.Channel,
+				Payload: n.Extra,
+				PID:     n.BePid,
+			})
+
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+
+		case <-ping.C:
+			// keep the client connection and the listener connection alive
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maskedValue replaces the values of masked columns in results
+const maskedValue = "***"
+
+// columnMask matches the result columns whose values must not be returned
+type columnMask struct {
+	names    []string
+	patterns []*regexp.Regexp
+}
+
+// newColumnMask compiles the masked columns of a connection. Entries are column
+// names, matched case-insensitively, or regular expressions enclosed in slashes
+// (e.g. "/^ssn|_ssn$/"). It returns nil if no columns are masked.
+func newColumnMask(entries []string) (*columnMask, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	mask := &columnMask{}
+
+	for _, entry := range entries {
+		if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			re, err := regexp.Compile("(?i)" + entry[1:len(entry)-1])
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid masked column pattern %s: %w", entry, err)
+			}
+
+			mask.patterns = append(mask.patterns, re)
+			continue
+		}
+
+		mask.names = append(mask.names, entry)
+	}
+
+	return mask, nil
+}
+
+// matches reports whether the values of a column are masked
+func (m *columnMask) matches(column string) bool {
+	if m == nil {
+		return false
+	}
+
+	if containsFold(m.names, column) {
+		return true
+	}
+
+	for _, re := range m.patterns {
+		if re.MatchString(column) {
+			return true
+		}
+	}
+
+	return false
+}
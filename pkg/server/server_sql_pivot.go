@@ -0,0 +1,423 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// PivotRequest contains a query whose result is pivoted into a crosstab
+type PivotRequest struct {
+	SQLRequest
+
+	RowKey    string `json:"rowKey"`    // column whose distinct values become the rows
+	ColumnKey string `json:"columnKey"` // column whose distinct values become the columns
+	Value     string `json:"value"`     // column holding the cell values
+
+	// Optional: how multiple values of a cell are combined,
+	// "first" (default), "sum", "count", "min", "max" or "avg"
+	Aggregate string `json:"aggregate,omitempty"`
+}
+
+const (
+	// pivotDefaultLimit caps the rows read from the query unless a limit is given
+	pivotDefaultLimit = 100000
+
+	// pivotMaxColumns caps the number of distinct column key values
+	pivotMaxColumns = 1000
+)
+
+// POST /sql/{connection}/pivot - Run a query and pivot the result around a row key,
+// a column key and a value column. The response holds one row per row key value,
+// starting with the row key, followed by one cell per column key value.
+func (s *Server) handlePivot(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req PivotRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	if req.RowKey == "" || req.ColumnKey == "" || req.Value == "" {
+		writeError(w, http.StatusBadRequest, "rowKey, columnKey and value are required")
+		return
+	}
+
+	if req.Aggregate == "" {
+		req.Aggregate = "first"
+	}
+
+	if !slices.Contains([]string{"first", "sum", "count", "min", "max", "avg"}, req.Aggregate) {
+		writeError(w, http.StatusBadRequest, "aggregate must be one of first, sum, count, min, max or avg")
+		return
+	}
+
+	if req.Limit < 0 {
+		writeError(w, http.StatusBadRequest, "limit must not be negative")
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = pivotDefaultLimit
+	}
+
+	mask, err := newColumnMask(conn.SQL.MaskedColumns)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	opts := resultOptions{
+		Limit: req.Limit,
+
+		DecimalsAsStrings: req.DecimalsAsStrings,
+
+		Mask: mask,
+	}
+
+	if req.TimeZone != "" {
+		location, err := time.LoadLocation(req.TimeZone)
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid time zone: "+req.TimeZone)
+			return
+		}
+
+		opts.Location = location
+	}
+
+	if err := rewritePlaceholders(conn.SQL.Driver, &req.SQLRequest); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := checkStatementPolicy(conn.SQL, req.Query); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkConfirmation(conn, &req.SQLRequest); err != nil {
+		writeErrorFrom(w, http.StatusPreconditionRequired, err)
+		return
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkReadOnly(conn.SQL, &req.SQLRequest); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params, err := bindParams(req.Params)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &req.SQLRequest); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	var q queryer = session
+
+	if readOnlyQueries(conn.SQL, &req.SQLRequest) {
+		tx, err := beginReadOnly(ctx, session, conn.SQL.Driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	start := time.Now()
+
+	rows, err := q.QueryContext(ctx, req.Query, params...)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer rows.Close()
+
+	columns, values, hasMore, err := rowsToArrays(rows, opts)
+
+	elapsed := time.Since(start)
+
+	s.metrics.observeQuery("query", elapsed)
+	s.logSlowQuery(connID, &req.SQLRequest, elapsed)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	pivotColumns, pivotRows, err := pivotRows(columns, values, req.RowKey, req.ColumnKey, req.Value, req.Aggregate)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := SQLResponse{
+		Columns: pivotColumns,
+		Rows:    pivotRows,
+		HasMore: hasMore,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// pivotRows builds a crosstab from positional rows. Row and column key values
+// keep the order in which they first appear in the result.
+func pivotRows(columns []string, rows [][]any, rowKey, columnKey, value, aggregate string) ([]string, [][]any, error) {
+	rowIndex := slices.Index(columns, rowKey)
+	columnIndex := slices.Index(columns, columnKey)
+	valueIndex := slices.Index(columns, value)
+
+	for name, i := range map[string]int{rowKey: rowIndex, columnKey: columnIndex, value: valueIndex} {
+		if i < 0 {
+			return nil, nil, fmt.Errorf("column %q not found in result", name)
+		}
+	}
+
+	var rowKeys []any
+	var columnKeys []string
+
+	rowPositions := make(map[string]int)
+	columnPositions := make(map[string]int)
+
+	// cells[row][column] collects all values of a cell
+	var cells [][][]any
+
+	for _, row := range rows {
+		rk := pivotKey(row[rowIndex])
+
+		r, ok := rowPositions[rk]
+
+		if !ok {
+			r = len(rowKeys)
+			rowPositions[rk] = r
+
+			rowKeys = append(rowKeys, row[rowIndex])
+			cells = append(cells, nil)
+		}
+
+		ck := pivotKey(row[columnIndex])
+
+		c, ok := columnPositions[ck]
+
+		if !ok {
+			if len(columnKeys) >= pivotMaxColumns {
+				return nil, nil, fmt.Errorf("column key %q has more than %d distinct values", columnKey, pivotMaxColumns)
+			}
+
+			c = len(columnKeys)
+			columnPositions[ck] = c
+
+			columnKeys = append(columnKeys, ck)
+		}
+
+		for len(cells[r]) <= c {
+			cells[r] = append(cells[r], nil)
+		}
+
+		cells[r][c] = append(cells[r][c], row[valueIndex])
+	}
+
+	result := make([][]any, len(rowKeys))
+
+	for r, key := range rowKeys {
+		out := make([]any, 1+len(columnKeys))
+		out[0] = key
+
+		for c := range columnKeys {
+			var values []any
+
+			if c < len(cells[r]) {
+				values = cells[r][c]
+			}
+
+			cell, err := aggregateCell(values, aggregate)
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("column %q: %w", value, err)
+			}
+
+			out[1+c] = cell
+		}
+
+		result[r] = out
+	}
+
+	return append([]string{rowKey}, columnKeys...), result, nil
+}
+
+// pivotKey formats a key value as text; NULL keys are named "NULL"
+func pivotKey(val any) string {
+	if val == nil {
+		return "NULL"
+	}
+
+	return csvValue(val)
+}
+
+// aggregateCell combines the values of a cell. NULL values are ignored,
+// empty cells are NULL (or 0 when counting).
+func aggregateCell(values []any, aggregate string) (any, error) {
+	if aggregate == "count" {
+		count := 0
+
+		for _, v := range values {
+			if v != nil {
+				count++
+			}
+		}
+
+		return count, nil
+	}
+
+	if aggregate == "first" {
+		for _, v := range values {
+			if v != nil {
+				return v, nil
+			}
+		}
+
+		return nil, nil
+	}
+
+	var result float64
+	var count int
+
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+
+		n, ok := numericValue(v)
+
+		if !ok {
+			return nil, fmt.Errorf("value %v is not numeric", v)
+		}
+
+		switch {
+		case count == 0:
+			result = n
+		case aggregate == "sum", aggregate == "avg":
+			result += n
+		case aggregate == "min":
+			result = min(result, n)
+		case aggregate == "max":
+			result = max(result, n)
+		}
+
+		count++
+	}
+
+	if count == 0 {
+		return nil, nil
+	}
+
+	if aggregate == "avg" {
+		result /= float64(count)
+	}
+
+	return result, nil
+}
+
+// numericValue converts a scanned value to a float64
+func numericValue(val any) (float64, bool) {
+	switch v := val.(type) {
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+
+	return 0, false
+}
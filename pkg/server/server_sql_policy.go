@@ -0,0 +1,260 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// checkStatementPolicy verifies every statement in a query against the
+// connection's allowed/denied statement types
+func checkStatementPolicy(config *SQLConfig, query string) error {
+	if len(config.AllowedStatements) == 0 && len(config.DeniedStatements) == 0 {
+		return nil
+	}
+
+	for _, stmt := range splitStatements(config.Driver, query) {
+		keyword := statementType(config.Driver, stmt)
+
+		if len(config.AllowedStatements) > 0 && !containsFold(config.AllowedStatements, keyword) {
+			return fmt.Errorf("%s statements are not allowed for this connection", keyword)
+		}
+
+		if containsFold(config.DeniedStatements, keyword) {
+			return fmt.Errorf("%s statements are not allowed for this connection", keyword)
+		}
+	}
+
+	return nil
+}
+
+// checkConfirmation requires the confirm flag for destructive statements
+// (e.g. DELETE, DROP, KILL) on connections marked as production
+func checkConfirmation(conn *Connection, req *SQLRequest) error {
+	if !strings.EqualFold(conn.Environment, "production") || req.Confirm {
+		return nil
+	}
+
+	for _, stmt := range splitStatements(conn.SQL.Driver, req.Query) {
+		switch keyword := statementType(conn.SQL.Driver, stmt); keyword {
+		case "DELETE", "UPDATE", "MERGE", "DROP", "TRUNCATE", "ALTER", "KILL":
+			return fmt.Errorf("%s statements on production connections must be confirmed", keyword)
+		}
+	}
+
+	return nil
+}
+
+// splitStatements splits a script into statements on top-level semicolons,
+// ignoring semicolons inside string literals, quoted identifiers and comments
+func splitStatements(driver, query string) []string {
+	var result []string
+
+	start := 0
+
+	scanSQL(driver, query, func(i int, r rune, depth int) {
+		if r == ';' {
+			if stmt := strings.TrimSpace(query[start:i]); stmt != "" {
+				result = append(result, stmt)
+			}
+
+			start = i + 1
+		}
+	})
+
+	if stmt := strings.TrimSpace(query[start:]); stmt != "" {
+		result = append(result, stmt)
+	}
+
+	return result
+}
+
+// statementType returns the upper-cased leading keyword of a statement.
+// For common table expressions (WITH ...) the most destructive data-modifying
+// keyword at any depth is returned, as CTEs like "WITH d AS (DELETE ...)" modify
+// data even if the main statement is a SELECT; otherwise the keyword of the main
+// statement following the CTE definitions.
+func statementType(driver, stmt string) string {
+	// parenthesized queries like "(SELECT ...) UNION (SELECT ...)"
+	words := topLevelWords(driver, strings.TrimLeft(stmt, "( \t\r\n"))
+
+	if len(words) == 0 {
+		return ""
+	}
+
+	if words[0] != "WITH" {
+		return words[0]
+	}
+
+	nested := sqlWords(driver, stmt, true)
+
+	for _, keyword := range []string{"DELETE", "MERGE", "UPDATE", "INSERT"} {
+		if slices.Contains(nested, keyword) {
+			return keyword
+		}
+	}
+
+	for _, word := range words[1:] {
+		switch word {
+		case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE":
+			return word
+		}
+	}
+
+	return words[0]
+}
+
+// topLevelWords returns the upper-cased keywords of a statement that are
+// not nested in parentheses, literals or comments
+func topLevelWords(driver, stmt string) []string {
+	return sqlWords(driver, stmt, false)
+}
+
+// sqlWords returns the upper-cased keywords of a statement outside of literals
+// and comments, including those nested in parentheses if nested is set
+func sqlWords(driver, stmt string, nested bool) []string {
+	var words []string
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, strings.ToUpper(word.String()))
+			word.Reset()
+		}
+	}
+
+	scanSQL(driver, stmt, func(i int, r rune, depth int) {
+		if (nested || depth == 0) && (unicode.IsLetter(r) || r == '_') {
+			word.WriteRune(r)
+			return
+		}
+
+		flush()
+	})
+
+	flush()
+	return words
+}
+
+// scanSQL calls fn for every rune of query that is outside of string literals,
+// quoted identifiers and comments, together with the current parenthesis depth.
+// The dialect of the driver decides about further quoting: PostgreSQL has
+// dollar-quoted strings ($$...$$ or $tag$...$tag$) and backslash escapes in
+// E'...' strings, MySQL has backslash escapes in all string literals.
+func scanSQL(driver, query string, fn func(i int, r rune, depth int)) {
+	depth := 0
+
+	postgres := driver == "postgres" || driver == "pgx"
+
+	var quote rune
+
+	// backslash escapes apply to the current string literal
+	var escapes bool
+
+	runes := []rune(query)
+	offsets := make([]int, 0, len(runes))
+
+	for i := range query {
+		offsets = append(offsets, i)
+	}
+
+	for n := 0; n < len(runes); n++ {
+		r := runes[n]
+
+		if quote != 0 {
+			if escapes && r == '\\' {
+				n++
+				continue
+			}
+
+			if r == quote {
+				// doubled quote characters are escapes
+				if n+1 < len(runes) && runes[n+1] == quote {
+					n++
+					continue
+				}
+
+				quote = 0
+			}
+
+			continue
+		}
+
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+
+			escapes = r == '\'' && (driver == "mysql" ||
+				(postgres && n > 0 && (runes[n-1] == 'E' || runes[n-1] == 'e') && (n < 2 || !isIdentifierRune(runes[n-2]))))
+
+			continue
+
+		case r == '$' && postgres && (n == 0 || !isIdentifierRune(runes[n-1])):
+			if end, ok := dollarQuoteEnd(runes, n); ok {
+				n = end
+				continue
+			}
+
+		case r == '-' && n+1 < len(runes) && runes[n+1] == '-':
+			for n < len(runes) && runes[n] != '\n' {
+				n++
+			}
+			continue
+
+		case r == '/' && n+1 < len(runes) && runes[n+1] == '*':
+			n += 2
+			for n+1 < len(runes) && !(runes[n] == '*' && runes[n+1] == '/') {
+				n++
+			}
+			n++
+			continue
+
+		case r == '(':
+			depth++
+
+		case r == ')':
+			if depth > 0 {
+				depth--
+			}
+		}
+
+		fn(offsets[n], r, depth)
+	}
+}
+
+// dollarQuoteEnd returns the index of the last rune of a dollar-quoted string
+// starting at runes[start], e.g. $$...$$ or $body$...$body$. Unterminated strings
+// extend to the end. It reports false if no dollar quote starts there (e.g. $1).
+func dollarQuoteEnd(runes []rune, start int) (int, bool) {
+	n := start + 1
+
+	for n < len(runes) && (unicode.IsLetter(runes[n]) || runes[n] == '_' || (n > start+1 && unicode.IsDigit(runes[n]))) {
+		n++
+	}
+
+	if n >= len(runes) || runes[n] != '$' {
+		return 0, false
+	}
+
+	tag := runes[start : n+1]
+
+	for i := n + 1; i+len(tag) <= len(runes); i++ {
+		if slices.Equal(runes[i:i+len(tag)], tag) {
+			return i + len(tag) - 1, true
+		}
+	}
+
+	return len(runes) - 1, true
+}
+
+// isIdentifierRune reports whether r can be part of an unquoted identifier
+func isIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$'
+}
+
+func containsFold(list []string, value string) bool {
+	return slices.ContainsFunc(list, func(s string) bool {
+		return strings.EqualFold(strings.TrimSpace(s), value)
+	})
+}
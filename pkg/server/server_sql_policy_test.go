@@ -0,0 +1,180 @@
+package server
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		query  string
+		want   []string
+	}{
+		{"single", "postgres", "SELECT 1", []string{"SELECT 1"}},
+		{"trailing semicolon", "postgres", "SELECT 1;", []string{"SELECT 1"}},
+		{"several", "sqlite", "SELECT 1; SELECT 2 ;\n SELECT 3", []string{"SELECT 1", "SELECT 2", "SELECT 3"}},
+		{"empty statements", "sqlite", ";; SELECT 1;;", []string{"SELECT 1"}},
+		{"string literal", "sqlite", "SELECT ';'; SELECT 2", []string{"SELECT ';'", "SELECT 2"}},
+		{"doubled quote", "sqlite", "SELECT 'x'';'; SELECT 2", []string{"SELECT 'x'';'", "SELECT 2"}},
+		{"quoted identifier", "sqlite", `SELECT "a;b" FROM t; SELECT 2`, []string{`SELECT "a;b" FROM t`, "SELECT 2"}},
+		{"backtick identifier", "mysql", "SELECT `a;b` FROM t; SELECT 2", []string{"SELECT `a;b` FROM t", "SELECT 2"}},
+		{"line comment", "sqlite", "SELECT 1 -- ;\n; SELECT 2", []string{"SELECT 1 -- ;", "SELECT 2"}},
+		{"block comment", "sqlite", "SELECT 1 /* ; */; SELECT 2", []string{"SELECT 1 /* ; */", "SELECT 2"}},
+
+		{"dollar quotes", "postgres",
+			"CREATE FUNCTION f() RETURNS int AS $$ BEGIN; RETURN 1; END; $$ LANGUAGE plpgsql; SELECT 1",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN; RETURN 1; END; $$ LANGUAGE plpgsql", "SELECT 1"}},
+		{"tagged dollar quotes", "pgx",
+			"DO $body$ BEGIN PERFORM ';'; END $body$; SELECT 2",
+			[]string{"DO $body$ BEGIN PERFORM ';'; END $body$", "SELECT 2"}},
+		{"nested dollar quotes", "postgres",
+			"DO $a$ SELECT $$;$$; $a$; SELECT 2",
+			[]string{"DO $a$ SELECT $$;$$; $a$", "SELECT 2"}},
+		{"dollar placeholder", "postgres", "SELECT $1; SELECT $2", []string{"SELECT $1", "SELECT $2"}},
+		{"dollar in identifier", "postgres", "SELECT a$b$ FROM t; SELECT 2", []string{"SELECT a$b$ FROM t", "SELECT 2"}},
+		{"no dollar quotes on mysql", "mysql", "SELECT $$; SELECT $$", []string{"SELECT $$", "SELECT $$"}},
+
+		{"mysql backslash escape", "mysql", `SELECT '\''; DELETE FROM t`, []string{`SELECT '\''`, "DELETE FROM t"}},
+		{"mysql escaped backslash", "mysql", `SELECT 'a\\'; DELETE FROM t`, []string{`SELECT 'a\\'`, "DELETE FROM t"}},
+		{"postgres literal backslash", "postgres", `SELECT 'C:\'; DELETE FROM t`, []string{`SELECT 'C:\'`, "DELETE FROM t"}},
+		{"postgres escape string", "postgres", `SELECT E'it\'s;'; DELETE FROM t`, []string{`SELECT E'it\'s;'`, "DELETE FROM t"}},
+		{"postgres identifier ending in e", "postgres", `SELECT name'x\'; SELECT 2`, []string{`SELECT name'x\'`, "SELECT 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.driver, tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatementType(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{"", ""},
+		{"select 1", "SELECT"},
+		{"  -- comment\n  delete from t", "DELETE"},
+		{"(SELECT 1) UNION (SELECT 2)", "SELECT"},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", "SELECT"},
+		{"WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", "INSERT"},
+		{"WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d", "DELETE"},
+		{"WITH u AS (UPDATE t SET a = 1 RETURNING *) SELECT * FROM u", "UPDATE"},
+		{"WITH i AS (INSERT INTO t VALUES (1) RETURNING *) SELECT * FROM i", "INSERT"},
+		{"WITH a AS (INSERT INTO t VALUES (1)), b AS (DELETE FROM t) SELECT 1", "DELETE"},
+		{"WITH x AS (SELECT 'DELETE') SELECT * FROM x", "SELECT"},
+		{"WITH RECURSIVE x AS (SELECT 1) SELECT * FROM x", "SELECT"},
+	}
+
+	for _, tt := range tests {
+		if got := statementType("postgres", tt.stmt); got != tt.want {
+			t.Errorf("statementType(%q) = %q, want %q", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestCheckStatementPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SQLConfig
+		query   string
+		wantErr bool
+	}{
+		{"no policy", SQLConfig{}, "DROP TABLE t", false},
+		{"allowed", SQLConfig{AllowedStatements: []string{"select"}}, "SELECT 1", false},
+		{"not allowed", SQLConfig{AllowedStatements: []string{"SELECT"}}, "SELECT 1; DELETE FROM t", true},
+		{"data-modifying CTE", SQLConfig{AllowedStatements: []string{"SELECT"}}, "WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d", true},
+		{"denied", SQLConfig{DeniedStatements: []string{"DROP"}}, "drop table t", true},
+		{"hidden by backslash", SQLConfig{Driver: "mysql", AllowedStatements: []string{"SELECT"}}, `SELECT '\''; DELETE FROM t`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkStatementPolicy(&tt.config, tt.query); (err != nil) != tt.wantErr {
+				t.Errorf("checkStatementPolicy(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckConfirmation(t *testing.T) {
+	production := &Connection{Environment: "production", SQL: &SQLConfig{Driver: "postgres"}}
+
+	tests := []struct {
+		name    string
+		conn    *Connection
+		req     SQLRequest
+		wantErr bool
+	}{
+		{"select", production, SQLRequest{Query: "SELECT 1"}, false},
+		{"delete", production, SQLRequest{Query: "DELETE FROM t"}, true},
+		{"confirmed", production, SQLRequest{Query: "DELETE FROM t", Confirm: true}, false},
+		{"data-modifying CTE", production, SQLRequest{Query: "WITH d AS (DELETE FROM t) SELECT 1"}, true},
+		{"not production", &Connection{SQL: &SQLConfig{Driver: "postgres"}}, SQLRequest{Query: "DROP TABLE t"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkConfirmation(tt.conn, &tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("checkConfirmation(%q) error = %v, wantErr %v", tt.req.Query, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExplainRollback(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		query   string
+		want    bool
+		wantErr bool
+	}{
+		{"plain explain", "postgres", "EXPLAIN DELETE FROM t", false, false},
+		{"analyzed select", "postgres", "EXPLAIN ANALYZE SELECT 1", true, false},
+		{"analyzed CTE", "postgres", "EXPLAIN ANALYZE WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d", true, false},
+		{"option list", "pgx", "EXPLAIN (ANALYZE, BUFFERS) UPDATE t SET a = 1", true, false},
+		{"mysql DDL", "mysql", "EXPLAIN ANALYZE DROP TABLE t", false, true},
+		{"sqlite select", "sqlite", "EXPLAIN ANALYZE SELECT 1", false, false},
+		{"sqlite delete", "sqlite", "EXPLAIN ANALYZE DELETE FROM t", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := explainRollback(tt.driver, tt.query)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("explainRollback(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("explainRollback(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripOrderBy(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{"SELECT * FROM t", "SELECT * FROM t"},
+		{"SELECT * FROM t ORDER BY a", "SELECT * FROM t"},
+		{"SELECT * FROM t ORDER BY a LIMIT 10", "SELECT * FROM t ORDER BY a LIMIT 10"},
+		{"SELECT TOP 5 * FROM t ORDER BY a", "SELECT TOP 5 * FROM t ORDER BY a"},
+		{"SELECT * FROM (SELECT * FROM t ORDER BY a) x", "SELECT * FROM (SELECT * FROM t ORDER BY a) x"},
+		{"SELECT * FROM t ORDER BY a <-> $1", "SELECT * FROM t ORDER BY a <-> $1"},
+	}
+
+	for _, tt := range tests {
+		if got := stripOrderBy("postgres", tt.stmt); got != tt.want {
+			t.Errorf("stripOrderBy(%q) = %q, want %q", tt.stmt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PreviewResponse contains the rows an UPDATE or DELETE statement would change
+type PreviewResponse struct {
+	// Supported is false if the statement could not be rewritten, Reason explains why
+	Supported bool   `json:"supported"`
+	Reason    string `json:"reason,omitempty"`
+
+	// Query is the SELECT statement the preview was computed with
+	Query string `json:"query,omitempty"`
+
+	Columns []string `json:"columns,omitempty"`
+	Rows    any      `json:"rows,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+}
+
+// sqlToken is a top-level keyword or identifier and its byte range in a statement
+type sqlToken struct {
+	word  string
+	start int
+	end   int
+}
+
+// POST /sql/{connection}/preview - Show the rows an UPDATE or DELETE would affect, without running it
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req SQLRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Format != "" && req.Format != "objects" && req.Format != "arrays" {
+		writeError(w, http.StatusBadRequest, "format must be \"objects\" or \"arrays\"")
+		return
+	}
+
+	params, err := bindParams(req.Params)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query, params, err := previewQuery(conn.SQL.Driver, req.Query, params)
+
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PreviewResponse{Reason: err.Error()})
+		return
+	}
+
+	if err := checkStatementPolicy(conn.SQL, query); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkReadOnly(conn.SQL, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mask, err := newColumnMask(conn.SQL.MaskedColumns)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	var q queryer = session
+
+	if readOnlyQueries(conn.SQL, &req) {
+		tx, err := beginReadOnly(ctx, session, conn.SQL.Driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	limit := req.Limit
+
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	start := time.Now()
+
+	rows, err := q.QueryContext(ctx, query, params...)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer rows.Close()
+
+	sets, err := resultSetsToJSON(rows, resultOptions{Format: req.Format, Limit: limit, Mask: mask})
+
+	elapsed := time.Since(start)
+
+	s.metrics.observeQuery("query", elapsed)
+	s.logSlowQuery(connID, &req, elapsed)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PreviewResponse{
+		Supported: true,
+		Query:     query,
+
+		Columns: sets[0].Columns,
+		Rows:    sets[0].Rows,
+		HasMore: sets[0].HasMore,
+	})
+}
+
+// previewQuery rewrites a single UPDATE or DELETE statement into a SELECT of
+// the rows it would change (best-effort). Parameters bound in the SET clause
+// are dropped for drivers with positional "?" placeholders; for numbered
+// placeholders the rewrite is refused, as the numbering would break.
+func previewQuery(driver, query string, params []any) (string, []any, error) {
+	statements := splitStatements(driver, query)
+
+	if len(statements) != 1 {
+		return "", nil, errors.New("preview requires a single statement")
+	}
+
+	stmt := statements[0]
+	tokens := topLevelTokens(driver, stmt)
+
+	if len(tokens) == 0 {
+		return "", nil, errors.New("empty statement")
+	}
+
+	find := func(word string) int {
+		for i, t := range tokens {
+			if t.word == word {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	for _, word := range []string{"WITH", "TOP", "USING", "OUTPUT", "RETURNING"} {
+		if find(word) >= 0 {
+			return "", nil, errors.New("preview is not supported for statements with " + word)
+		}
+	}
+
+	where := len(stmt)
+
+	if i := find("WHERE"); i >= 0 {
+		where = tokens[i].start
+	}
+
+	var table, removed string
+
+	switch tokens[0].word {
+	case "DELETE":
+		from := tokens[0].end
+
+		if len(tokens) > 1 && tokens[1].word == "FROM" {
+			from = tokens[1].end
+		}
+
+		table = stmt[from:where]
+
+	case "UPDATE":
+		set := find("SET")
+
+		if set < 0 {
+			return "", nil, errors.New("UPDATE without SET clause")
+		}
+
+		if find("FROM") >= 0 {
+			return "", nil, errors.New("preview is not supported for UPDATE ... FROM")
+		}
+
+		if tokens[set].start > where {
+			return "", nil, errors.New("unexpected WHERE before SET")
+		}
+
+		table = stmt[tokens[0].end:tokens[set].start]
+		removed = stmt[tokens[set].start:where]
+
+	default:
+		return "", nil, errors.New("preview is only supported for UPDATE and DELETE statements")
+	}
+
+	if strings.TrimSpace(table) == "" {
+		return "", nil, errors.New("could not determine the target table")
+	}
+
+	if n := countPlaceholders(driver, removed); n > 0 {
+		if placeholder(driver, 1) != "?" {
+			return "", nil, errors.New("preview is not supported for parameters in the SET clause")
+		}
+
+		if n > len(params) {
+			return "", nil, errors.New("not enough parameters")
+		}
+
+		params = params[n:]
+	}
+
+	return strings.TrimSpace("SELECT * FROM " + strings.TrimSpace(table) + " " + stmt[where:]), params, nil
+}
+
+// topLevelTokens returns the words of a statement that are not nested in
+// parentheses, literals or comments, upper-cased and with their byte ranges
+func topLevelTokens(driver, stmt string) []sqlToken {
+	var tokens []sqlToken
+
+	start, end := -1, 0
+
+	flush := func() {
+		if start >= 0 {
+			tokens = append(tokens, sqlToken{word: strings.ToUpper(stmt[start:end]), start: start, end: end})
+			start = -1
+		}
+	}
+
+	scanSQL(driver, stmt, func(i int, r rune, depth int) {
+		isWord := depth == 0 && (unicode.IsLetter(r) || r == '_' || (start >= 0 && unicode.IsDigit(r)))
+
+		// words end at anything skipped in between, e.g. comments
+		if !isWord || (start >= 0 && i != end) {
+			flush()
+		}
+
+		if isWord && (unicode.IsLetter(r) || r == '_' || start >= 0) {
+			if start < 0 {
+				start = i
+			}
+
+			end = i + utf8.RuneLen(r)
+		}
+	})
+
+	flush()
+	return tokens
+}
+
+// countPlaceholders counts the bind parameter placeholders in a SQL fragment
+func countPlaceholders(driver, fragment string) int {
+	count := 0
+
+	scanSQL(driver, fragment, func(i int, r rune, depth int) {
+		rest := fragment[i+utf8.RuneLen(r):]
+		next, _ := utf8.DecodeRuneInString(rest)
+
+		switch {
+		case r == '?':
+			count++
+
+		case (r == '$' || r == ':') && unicode.IsDigit(next):
+			count++
+
+		case r == '@' && (next == 'p' || next == 'P'):
+			count++
+		}
+	})
+
+	return count
+}
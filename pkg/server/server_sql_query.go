@@ -1,10 +1,11 @@
 package server
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"net/http"
 	"os"
+	"time"
 )
 
 func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
@@ -16,7 +17,7 @@ func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -27,47 +28,208 @@ func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
 
 	var req SQLRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := decodeParams(r.Body, &req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Format != "" && req.Format != "objects" && req.Format != "arrays" {
+		writeError(w, http.StatusBadRequest, "format must be \"objects\" or \"arrays\"")
 		return
 	}
 
-	// Modify DSN if a specific database is requested
-	dsn := modifyDSNForDatabase(conn.SQL.Driver, conn.SQL.DSN, req.Database)
+	if req.Offset < 0 || req.Limit < 0 {
+		writeError(w, http.StatusBadRequest, "offset and limit must not be negative")
+		return
+	}
 
-	db, err := sql.Open(conn.SQL.Driver, dsn)
+	mask, err := newColumnMask(conn.SQL.MaskedColumns)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "Failed to open database: "+err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
-	defer db.Close()
+	opts := resultOptions{
+		Format: req.Format,
+
+		Offset: req.Offset,
+		Limit:  req.Limit,
 
-	if err := db.Ping(); err != nil {
-		writeError(w, http.StatusBadRequest, "Failed to connect to database: "+err.Error())
+		Columns: req.Columns,
+
+		DecimalsAsStrings: req.DecimalsAsStrings,
+
+		Mask: mask,
+	}
+
+	if req.TimeZone != "" {
+		location, err := time.LoadLocation(req.TimeZone)
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid time zone: "+req.TimeZone)
+			return
+		}
+
+		opts.Location = location
+	}
+
+	if err := rewritePlaceholders(conn.SQL.Driver, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	rows, err := db.Query(req.Query, req.Params...)
+	if err := checkStatementPolicy(conn.SQL, req.Query); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkConfirmation(conn, &req); err != nil {
+		writeErrorFrom(w, http.StatusPreconditionRequired, err)
+		return
+	}
+
+	if err := checkRole(conn.SQL, req.Role); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := checkReadOnly(conn.SQL, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params, err := bindParams(req.Params)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rollback, err := explainRollback(conn.SQL.Driver, req.Query)
 
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var session sqlSession = db
+
+	if req.Role != "" {
+		c, release, err := assumeRole(ctx, db, conn.SQL.Driver, req.Role)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		defer release()
+
+		session = c
+	}
+
+	var q queryer = session
+
+	switch {
+	case readOnlyQueries(conn.SQL, &req):
+		tx, err := beginReadOnly(ctx, session, conn.SQL.Driver)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// nothing is ever committed, writes already fail inside the transaction
+		defer tx.Rollback()
+
+		q = tx
+
+	case rollback:
+		tx, err := session.BeginTx(ctx, nil)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// EXPLAIN ANALYZE executes the statement, its changes are always discarded
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	start := time.Now()
+
+	rows, err := q.QueryContext(ctx, req.Query, params...)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
 	defer rows.Close()
 
-	columns, data, err := rowsToJSON(rows)
+	sets, err := resultSetsToJSON(rows, opts)
+
+	elapsed := time.Since(start)
+
+	s.metrics.observeQuery("query", elapsed)
+	s.logSlowQuery(connID, &req, elapsed)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
 	resp := SQLResponse{
-		Columns: columns,
-		Rows:    data,
+		Columns: sets[0].Columns,
+		Rows:    sets[0].Rows,
+		HasMore: sets[0].HasMore,
+	}
+
+	if len(sets) > 1 {
+		resp.ResultSets = sets
+	}
+
+	if req.Offset > 0 || req.Limit > 0 {
+		resp.RowStart = &req.Offset
+	}
+
+	if req.Count {
+		rows.Close()
+
+		count, err := countRows(ctx, q, conn.SQL.Driver, req.Query, params)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		resp.TotalRows = &count
 	}
 
 	w.Header().Set("Content-Type", "application/json")
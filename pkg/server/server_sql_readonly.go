@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// readOnlyQueries reports whether the queries of a request run in a read-only
+// transaction, as enforced by the connection or asked for by the request
+func readOnlyQueries(config *SQLConfig, req *SQLRequest) bool {
+	return config.ReadOnlyTransactions || req.ReadOnly
+}
+
+// checkReadOnly verifies that the driver supports read-only transactions if the
+// queries of a request must run in one
+func checkReadOnly(config *SQLConfig, req *SQLRequest) error {
+	if !readOnlyQueries(config, req) {
+		return nil
+	}
+
+	switch config.Driver {
+	case "postgres", "pgx", "mysql", "oracle":
+		return nil
+	}
+
+	return fmt.Errorf("read-only transactions are not supported for driver %s", config.Driver)
+}
+
+// beginReadOnly starts a transaction in which the database rejects writes, e.g.
+// by functions with side effects called from a query. Callers must roll it back.
+func beginReadOnly(ctx context.Context, session sqlSession, driver string) (*sql.Tx, error) {
+	if driver == "oracle" {
+		// go-ora rejects the ReadOnly option, the transaction is switched explicitly
+		tx, err := session.BeginTx(ctx, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		return tx, nil
+	}
+
+	return session.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+}
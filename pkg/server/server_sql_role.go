@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// sqlSession is implemented by *sql.DB and *sql.Conn
+type sqlSession interface {
+	queryer
+
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// checkRole verifies that a request may impersonate role on a connection.
+// Only roles listed in the connection's AllowedRoles can be assumed.
+func checkRole(config *SQLConfig, role string) error {
+	if role == "" {
+		return nil
+	}
+
+	switch config.Driver {
+	case "postgres", "pgx", "sqlserver":
+	default:
+		return fmt.Errorf("role impersonation is not supported for driver %s", config.Driver)
+	}
+
+	if !containsFold(config.AllowedRoles, role) {
+		return fmt.Errorf("role %s is not allowed for this connection", role)
+	}
+
+	return nil
+}
+
+// assumeRole pins a connection of db and switches it to role: SET ROLE on
+// PostgreSQL, EXECUTE AS USER on SQL Server. The returned release function
+// resets the role and returns the connection to the pool.
+func assumeRole(ctx context.Context, db *sql.DB, driver, role string) (*sql.Conn, func(), error) {
+	conn, err := db.Conn(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var assume, reset string
+
+	switch driver {
+	case "postgres", "pgx":
+		assume = "SET ROLE " + quoteIdentifier(driver, role)
+		reset = "RESET ROLE"
+
+	case "sqlserver":
+		assume = "EXECUTE AS USER = N'" + strings.ReplaceAll(role, "'", "''") + "'"
+		reset = "REVERT"
+
+	default:
+		conn.Close()
+		return nil, nil, fmt.Errorf("role impersonation is not supported for driver %s", driver)
+	}
+
+	if _, err := conn.ExecContext(ctx, assume); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to assume role %s: %w", role, err)
+	}
+
+	release := func() {
+		// the request context may already be done, the reset must still run
+		conn.ExecContext(context.WithoutCancel(ctx), reset)
+		conn.Close()
+	}
+
+	return conn, release, nil
+}
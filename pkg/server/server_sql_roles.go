@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// RolesRequest contains parameters for listing database users and roles
+type RolesRequest struct {
+	Database string `json:"database,omitempty"` // Optional: specify which database to connect to
+}
+
+// SQLRole describes a database user or role and the privileges granted to it
+type SQLRole struct {
+	Name      string `json:"name"`
+	CanLogin  bool   `json:"canLogin"`
+	Superuser bool   `json:"superuser"`
+
+	MemberOf   []string       `json:"memberOf"`
+	Privileges []SQLPrivilege `json:"privileges"`
+}
+
+// SQLPrivilege describes a single granted privilege. Schema and Object are
+// empty for privileges granted on the whole server or database.
+type SQLPrivilege struct {
+	Privilege string `json:"privilege"`
+	Schema    string `json:"schema,omitempty"`
+	Object    string `json:"object,omitempty"`
+	Grantable bool   `json:"grantable"`
+}
+
+// roleQueries are the catalog queries used to collect roles for a driver:
+// roles returns (name, canLogin, superuser), members (member, role) and
+// grants (grantee, privilege, schema, object, grantable)
+type roleQueries struct {
+	roles   string
+	members string
+	grants  string
+}
+
+// POST /sql/{connection}/roles - List database users and roles with their privileges
+func (s *Server) handleRoles(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req RolesRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	roles, err := listRoles(ctx, db, conn.SQL.Driver)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(roles)
+}
+
+// listRoles reads users, roles, role memberships and table privileges from
+// the driver's catalog and normalizes them into one list of roles
+func listRoles(ctx context.Context, db *sql.DB, driver string) ([]SQLRole, error) {
+	var q roleQueries
+
+	switch driver {
+	case "postgres", "pgx":
+		q = roleQueries{
+			roles: `
+				SELECT rolname, rolcanlogin, rolsuper
+				FROM pg_roles
+				WHERE rolname NOT LIKE 'pg\_%'
+				ORDER BY rolname`,
+			members: `
+				SELECT m.rolname, r.rolname
+				FROM pg_auth_members am
+				JOIN pg_roles m ON m.oid = am.member
+				JOIN pg_roles r ON r.oid = am.roleid`,
+			grants: `
+				SELECT grantee, privilege_type, table_schema, table_name, is_grantable = 'YES'
+				FROM information_schema.role_table_grants
+				WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+				ORDER BY grantee, table_schema, table_name, privilege_type`,
+		}
+
+	case "mysql":
+		// Accounts are identified as user@host, matching the GRANTEE columns with their quotes removed
+		q = roleQueries{
+			roles: `
+				SELECT CONCAT(User, '@', Host), account_locked = 'N', Super_priv = 'Y'
+				FROM mysql.user
+				ORDER BY User, Host`,
+			members: `
+				SELECT CONCAT(TO_USER, '@', TO_HOST), CONCAT(FROM_USER, '@', FROM_HOST)
+				FROM mysql.role_edges`,
+			grants: `
+				SELECT REPLACE(GRANTEE, '''', ''), PRIVILEGE_TYPE, '', '', IS_GRANTABLE = 'YES'
+				FROM information_schema.USER_PRIVILEGES
+				UNION ALL
+				SELECT REPLACE(GRANTEE, '''', ''), PRIVILEGE_TYPE, TABLE_SCHEMA, '', IS_GRANTABLE = 'YES'
+				FROM information_schema.SCHEMA_PRIVILEGES
+				UNION ALL
+				SELECT REPLACE(GRANTEE, '''', ''), PRIVILEGE_TYPE, TABLE_SCHEMA, TABLE_NAME, IS_GRANTABLE = 'YES'
+				FROM information_schema.TABLE_PRIVILEGES`,
+		}
+
+	case "sqlserver":
+		// Database-level principals: users (S, U, G, E, X) can log in, roles (R) cannot
+		q = roleQueries{
+			roles: `
+				SELECT name, CAST(CASE WHEN type = 'R' THEN 0 ELSE 1 END AS bit), CAST(CASE WHEN name = 'dbo' THEN 1 ELSE 0 END AS bit)
+				FROM sys.database_principals
+				WHERE type IN ('S', 'U', 'G', 'E', 'X', 'R') AND name NOT LIKE '##%'
+				ORDER BY name`,
+			members: `
+				SELECT m.name, r.name
+				FROM sys.database_role_members rm
+				JOIN sys.database_principals m ON m.principal_id = rm.member_principal_id
+				JOIN sys.database_principals r ON r.principal_id = rm.role_principal_id`,
+			grants: `
+				SELECT pr.name, p.permission_name,
+				       COALESCE(OBJECT_SCHEMA_NAME(p.major_id), SCHEMA_NAME(CASE WHEN p.class = 3 THEN p.major_id END), ''),
+				       COALESCE(OBJECT_NAME(CASE WHEN p.class = 1 THEN p.major_id END), ''),
+				       CAST(CASE WHEN p.state = 'W' THEN 1 ELSE 0 END AS bit)
+				FROM sys.database_permissions p
+				JOIN sys.database_principals pr ON pr.principal_id = p.grantee_principal_id
+				WHERE p.state IN ('G', 'W')
+				ORDER BY pr.name, p.permission_name`,
+		}
+
+	default:
+		return nil, fmt.Errorf("roles are not supported for driver %q", driver)
+	}
+
+	roles := make([]SQLRole, 0)
+	index := make(map[string]int)
+
+	rows, err := db.QueryContext(ctx, q.roles)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var role SQLRole
+
+		if err := rows.Scan(&role.Name, &role.CanLogin, &role.Superuser); err != nil {
+			return nil, err
+		}
+
+		role.MemberOf = make([]string, 0)
+		role.Privileges = make([]SQLPrivilege, 0)
+
+		index[role.Name] = len(roles)
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows.Close()
+
+	members, err := db.QueryContext(ctx, q.members)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer members.Close()
+
+	for members.Next() {
+		var member, role string
+
+		if err := members.Scan(&member, &role); err != nil {
+			return nil, err
+		}
+
+		if i, ok := index[member]; ok {
+			roles[i].MemberOf = append(roles[i].MemberOf, role)
+		}
+	}
+
+	if err := members.Err(); err != nil {
+		return nil, err
+	}
+
+	members.Close()
+
+	grants, err := db.QueryContext(ctx, q.grants)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer grants.Close()
+
+	for grants.Next() {
+		var grantee string
+		var privilege SQLPrivilege
+
+		if err := grants.Scan(&grantee, &privilege.Privilege, &privilege.Schema, &privilege.Object, &privilege.Grantable); err != nil {
+			return nil, err
+		}
+
+		// Grants to PUBLIC or roles outside the listing are reported as separate entries
+		i, ok := index[grantee]
+
+		if !ok {
+			i = len(roles)
+			index[grantee] = i
+
+			roles = append(roles, SQLRole{
+				Name:       grantee,
+				MemberOf:   make([]string, 0),
+				Privileges: make([]SQLPrivilege, 0),
+			})
+		}
+
+		roles[i].Privileges = append(roles[i].Privileges, privilege)
+	}
+
+	return roles, grants.Err()
+}
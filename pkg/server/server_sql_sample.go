@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// SampleTablesRequest contains the tables to fetch sample rows from
+type SampleTablesRequest struct {
+	Tables   []SampleTable `json:"tables"`
+	Limit    int           `json:"limit,omitempty"`    // Optional: rows per table (default 10, at most 100)
+	Database string        `json:"database,omitempty"` // Optional: specify which database to query
+}
+
+// SampleTable identifies a table to sample
+type SampleTable struct {
+	Table  string `json:"table"`
+	Schema string `json:"schema,omitempty"`
+}
+
+// TableSample contains the sample rows of a table, or the error sampling it failed with
+type TableSample struct {
+	Columns []string         `json:"columns,omitempty"`
+	Rows    []map[string]any `json:"rows,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// sampleConcurrency bounds the number of tables sampled at the same time
+const sampleConcurrency = 4
+
+// POST /sql/{connection}/tables/sample - Fetch the first rows of several tables.
+// The response maps "schema.table" (or "table") to its sample.
+func (s *Server) handleSampleTables(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req SampleTablesRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if len(req.Tables) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one table is required")
+		return
+	}
+
+	for _, t := range req.Tables {
+		if t.Table == "" {
+			writeError(w, http.StatusBadRequest, "table is required")
+			return
+		}
+	}
+
+	if err := checkReadOnly(conn.SQL, &SQLRequest{}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit := req.Limit
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	limit = min(limit, 100)
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &SQLRequest{}); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := make(map[string]TableSample, len(req.Tables))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, sampleConcurrency)
+
+	for _, t := range req.Tables {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			sample := sampleTable(ctx, db, conn.SQL, t, limit)
+
+			name := t.Table
+
+			if t.Schema != "" {
+				name = t.Schema + "." + t.Table
+			}
+
+			mu.Lock()
+			result[name] = sample
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// sampleTable reads the first rows of a table
+func sampleTable(ctx context.Context, db *sql.DB, cfg *SQLConfig, t SampleTable, limit int) TableSample {
+	query := sampleQuery(cfg.Driver, t.Schema, t.Table, limit)
+
+	if err := checkStatementPolicy(cfg, query); err != nil {
+		return TableSample{Error: err.Error()}
+	}
+
+	mask, err := newColumnMask(cfg.MaskedColumns)
+
+	if err != nil {
+		return TableSample{Error: err.Error()}
+	}
+
+	var q queryer = db
+
+	// tables are sampled concurrently, so each one gets its own transaction
+	if readOnlyQueries(cfg, &SQLRequest{}) {
+		tx, err := beginReadOnly(ctx, db, cfg.Driver)
+
+		if err != nil {
+			return TableSample{Error: err.Error()}
+		}
+
+		defer tx.Rollback()
+
+		q = tx
+	}
+
+	rows, err := q.QueryContext(ctx, query)
+
+	if err != nil {
+		return TableSample{Error: err.Error()}
+	}
+
+	defer rows.Close()
+
+	columns, data, _, err := rowsToJSON(rows, resultOptions{Limit: limit, Mask: mask})
+
+	if err != nil {
+		return TableSample{Error: err.Error()}
+	}
+
+	return TableSample{Columns: columns, Rows: data}
+}
+
+// sampleQuery builds a statement selecting the first rows of a table in the driver's dialect
+func sampleQuery(driver, schema, table string, limit int) string {
+	name := quoteTableName(driver, schema, table)
+	n := strconv.Itoa(limit)
+
+	switch driver {
+	case "sqlserver":
+		return "SELECT TOP " + n + " * FROM " + name
+
+	case "oracle":
+		return "SELECT * FROM " + name + " FETCH FIRST " + n + " ROWS ONLY"
+
+	default:
+		return "SELECT * FROM " + name + " LIMIT " + n
+	}
+}
@@ -0,0 +1,420 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schemaCacheTTL bounds how long an introspected schema is reused
+const schemaCacheTTL = time.Minute
+
+// SchemaRequest contains parameters for schema introspection
+type SchemaRequest struct {
+	Database string `json:"database,omitempty"` // Optional: specify which database to inspect
+}
+
+// SchemaTable describes a table or view and its columns
+type SchemaTable struct {
+	Schema  string         `json:"schema,omitempty"`
+	Name    string         `json:"name"`
+	Comment string         `json:"comment,omitempty"`
+	Columns []SchemaColumn `json:"columns"`
+}
+
+// SchemaColumn describes a table column
+type SchemaColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// CompleteRequest contains parameters for editor autocompletion
+type CompleteRequest struct {
+	Prefix   string `json:"prefix"`             // Table or column prefix, "table.prefix" restricts to a table's columns
+	Database string `json:"database,omitempty"` // Optional: specify which database to use
+	Limit    int    `json:"limit,omitempty"`    // Optional: maximum number of suggestions (default 100)
+}
+
+// CompletionItem is a single autocompletion suggestion
+type CompletionItem struct {
+	Kind   string `json:"kind"` // "table" or "column"
+	Name   string `json:"name"`
+	Schema string `json:"schema,omitempty"`
+	Table  string `json:"table,omitempty"`
+	Type   string `json:"type,omitempty"`
+}
+
+// schemaCache caches introspected schemas per connection and database
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[string]schemaCacheEntry
+}
+
+type schemaCacheEntry struct {
+	tables  []SchemaTable
+	expires time.Time
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{
+		entries: make(map[string]schemaCacheEntry),
+	}
+}
+
+func schemaCacheKey(connID, database string) string {
+	return normalizeConnectionID(connID) + "\x00" + database
+}
+
+func (c *schemaCache) get(connID, database string) ([]SchemaTable, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[schemaCacheKey(connID, database)]
+
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	return entry.tables, true
+}
+
+func (c *schemaCache) set(connID, database string, tables []SchemaTable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[schemaCacheKey(connID, database)] = schemaCacheEntry{
+		tables:  tables,
+		expires: time.Now().Add(schemaCacheTTL),
+	}
+}
+
+// invalidate drops the cached schemas of all databases of a connection
+func (c *schemaCache) invalidate(connID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, schemaCacheKey(connID, "")) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// POST /sql/{connection}/schema - Get the tables and columns of a database
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req SchemaRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	tables, err := s.loadSchema(r.Context(), conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tables)
+}
+
+// POST /connections/{id}/schema/refresh - Drop the cached schemas of a connection so
+// the next schema or completion request introspects the database again
+func (s *Server) handleSchemaRefresh(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	conn, err := s.getConnection(id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	s.schemas.invalidate(id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /sql/{connection}/complete - Get table and column names matching a prefix
+func (s *Server) handleComplete(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req CompleteRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	limit := req.Limit
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	tables, err := s.loadSchema(r.Context(), conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	items := completeSchema(tables, req.Prefix, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// completeSchema returns tables and columns whose names start with prefix (case-insensitive).
+// A prefix of the form "table.col" only matches columns of that table.
+func completeSchema(tables []SchemaTable, prefix string, limit int) []CompletionItem {
+	items := make([]CompletionItem, 0)
+
+	if table, column, ok := strings.Cut(prefix, "."); ok {
+		for _, t := range tables {
+			if !strings.EqualFold(t.Name, table) {
+				continue
+			}
+
+			for _, c := range t.Columns {
+				if len(items) >= limit {
+					return items
+				}
+
+				if hasPrefixFold(c.Name, column) {
+					items = append(items, CompletionItem{Kind: "column", Name: c.Name, Schema: t.Schema, Table: t.Name, Type: c.Type})
+				}
+			}
+		}
+
+		return items
+	}
+
+	for _, t := range tables {
+		if len(items) >= limit {
+			return items
+		}
+
+		if hasPrefixFold(t.Name, prefix) {
+			items = append(items, CompletionItem{Kind: "table", Name: t.Name, Schema: t.Schema})
+		}
+	}
+
+	seen := make(map[string]bool)
+
+	for _, t := range tables {
+		for _, c := range t.Columns {
+			if len(items) >= limit {
+				return items
+			}
+
+			if seen[c.Name] || !hasPrefixFold(c.Name, prefix) {
+				continue
+			}
+
+			seen[c.Name] = true
+			items = append(items, CompletionItem{Kind: "column", Name: c.Name, Type: c.Type})
+		}
+	}
+
+	return items
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// loadSchema returns the cached schema of a connection's database, introspecting it on a miss
+func (s *Server) loadSchema(ctx context.Context, conn *Connection, database string) ([]SchemaTable, error) {
+	if tables, ok := s.schemas.get(conn.ID, database); ok {
+		return tables, nil
+	}
+
+	db, err := s.openDatabase(ctx, conn, database)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	tables, err := introspectSchema(ctx, db, conn.SQL.Driver)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.schemas.set(conn.ID, database, tables)
+	return tables, nil
+}
+
+// introspectSchema reads all tables and columns of the current database from the catalog,
+// including table and column comments where the catalog exposes them
+func introspectSchema(ctx context.Context, db *sql.DB, driver string) ([]SchemaTable, error) {
+	var query string
+
+	switch driver {
+	case "postgres", "pgx":
+		query = `
+			SELECT table_schema, table_name, column_name, data_type, is_nullable,
+				col_description(format('%I.%I', table_schema, table_name)::regclass, ordinal_position::int),
+				obj_description(format('%I.%I', table_schema, table_name)::regclass, 'pg_class')
+			FROM information_schema.columns
+			WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+			ORDER BY table_schema, table_name, ordinal_position`
+
+	case "mysql":
+		query = `
+			SELECT c.table_schema, c.table_name, c.column_name, c.column_type, c.is_nullable,
+				c.column_comment, CASE WHEN t.table_type = 'VIEW' THEN '' ELSE t.table_comment END
+			FROM information_schema.columns c
+			JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+			WHERE c.table_schema = DATABASE()
+			ORDER BY c.table_name, c.ordinal_position`
+
+	case "sqlserver":
+		query = `
+			SELECT c.TABLE_SCHEMA, c.TABLE_NAME, c.COLUMN_NAME, c.DATA_TYPE, c.IS_NULLABLE,
+				CAST(cp.value AS NVARCHAR(MAX)), CAST(tp.value AS NVARCHAR(MAX))
+			FROM INFORMATION_SCHEMA.COLUMNS c
+			LEFT JOIN sys.extended_properties cp
+				ON cp.class = 1 AND cp.name = 'MS_Description'
+				AND cp.major_id = OBJECT_ID(QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME))
+				AND cp.minor_id = COLUMNPROPERTY(cp.major_id, c.COLUMN_NAME, 'ColumnId')
+			LEFT JOIN sys.extended_properties tp
+				ON tp.class = 1 AND tp.name = 'MS_Description' AND tp.minor_id = 0
+				AND tp.major_id = OBJECT_ID(QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME))
+			ORDER BY c.TABLE_SCHEMA, c.TABLE_NAME, c.ORDINAL_POSITION`
+
+	case "sqlite":
+		query = `
+			SELECT '', m.name, p.name, p.type, CASE WHEN p."notnull" = 0 THEN 'YES' ELSE 'NO' END, NULL, NULL
+			FROM sqlite_master m
+			JOIN pragma_table_info(m.name) p
+			WHERE m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%'
+			ORDER BY m.name, p.cid`
+
+	case "oracle":
+		query = `
+			SELECT c.OWNER, c.TABLE_NAME, c.COLUMN_NAME, c.DATA_TYPE, c.NULLABLE, cc.COMMENTS, tc.COMMENTS
+			FROM ALL_TAB_COLUMNS c
+			LEFT JOIN ALL_COL_COMMENTS cc ON cc.OWNER = c.OWNER AND cc.TABLE_NAME = c.TABLE_NAME AND cc.COLUMN_NAME = c.COLUMN_NAME
+			LEFT JOIN ALL_TAB_COMMENTS tc ON tc.OWNER = c.OWNER AND tc.TABLE_NAME = c.TABLE_NAME
+			WHERE c.OWNER = SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA')
+			ORDER BY c.TABLE_NAME, c.COLUMN_ID`
+
+	case "trino":
+		query = `
+			SELECT table_schema, table_name, column_name, data_type, is_nullable, NULL, NULL
+			FROM information_schema.columns
+			WHERE table_schema <> 'information_schema'
+			ORDER BY table_schema, table_name, ordinal_position`
+
+	default:
+		return nil, fmt.Errorf("schema introspection is not supported for driver %q", driver)
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tables := make([]SchemaTable, 0)
+
+	for rows.Next() {
+		var schema, table, column, dataType, nullable, columnComment, tableComment sql.NullString
+
+		if err := rows.Scan(&schema, &table, &column, &dataType, &nullable, &columnComment, &tableComment); err != nil {
+			return nil, err
+		}
+
+		if n := len(tables); n == 0 || tables[n-1].Schema != schema.String || tables[n-1].Name != table.String {
+			tables = append(tables, SchemaTable{
+				Schema:  schema.String,
+				Name:    table.String,
+				Comment: tableComment.String,
+				Columns: make([]SchemaColumn, 0),
+			})
+		}
+
+		t := &tables[len(tables)-1]
+
+		t.Columns = append(t.Columns, SchemaColumn{
+			Name:     column.String,
+			Type:     dataType.String,
+			Nullable: strings.EqualFold(nullable.String, "YES") || strings.EqualFold(nullable.String, "Y"),
+			Comment:  columnComment.String,
+		})
+	}
+
+	return tables, rows.Err()
+}
+
+// isSchemaChange reports whether a query contains DDL that invalidates cached schemas
+func isSchemaChange(driver, query string) bool {
+	for _, stmt := range splitStatements(driver, query) {
+		switch statementType(driver, stmt) {
+		case "CREATE", "ALTER", "DROP", "RENAME":
+			return true
+		}
+	}
+
+	return false
+}
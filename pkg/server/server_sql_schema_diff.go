@@ -0,0 +1,203 @@
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// SchemaDiffRequest contains the two connections whose schemas are compared
+type SchemaDiffRequest struct {
+	Source string `json:"source"` // Connection ID of the reference schema (e.g. production)
+	Target string `json:"target"` // Connection ID of the schema checked for drift (e.g. staging)
+
+	SourceDatabase string `json:"sourceDatabase,omitempty"` // Optional: database to inspect on the source
+	TargetDatabase string `json:"targetDatabase,omitempty"` // Optional: database to inspect on the target
+}
+
+// SchemaDiffResponse lists the differences of the target schema compared to the source
+type SchemaDiffResponse struct {
+	MissingTables []SchemaTableRef `json:"missingTables"` // tables of the source that the target lacks
+	ExtraTables   []SchemaTableRef `json:"extraTables"`   // tables of the target that the source lacks
+
+	Columns []SchemaColumnDiff `json:"columns"` // column differences of tables present on both sides
+}
+
+// SchemaTableRef identifies a table or view
+type SchemaTableRef struct {
+	Schema string `json:"schema,omitempty"`
+	Name   string `json:"name"`
+}
+
+// SchemaColumnDiff describes a column that differs between source and target
+type SchemaColumnDiff struct {
+	Schema string `json:"schema,omitempty"`
+	Table  string `json:"table"`
+	Column string `json:"column"`
+
+	Kind string `json:"kind"` // "missing" (only in source), "extra" (only in target) or "type" (types differ)
+
+	SourceType string `json:"sourceType,omitempty"`
+	TargetType string `json:"targetType,omitempty"`
+}
+
+// POST /sql/schema/diff - Compare the tables and columns of two connections
+func (s *Server) handleSchemaDiff(w http.ResponseWriter, r *http.Request) {
+	var req SchemaDiffRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if req.Source == "" || req.Target == "" {
+		writeError(w, http.StatusBadRequest, "source and target connections are required")
+		return
+	}
+
+	ctx := r.Context()
+
+	var schemas [2][]SchemaTable
+
+	// MySQL reports the database name as schema, which usually differs between environments
+	ignoreSchema := false
+
+	for i, side := range [2]struct{ id, database string }{
+		{req.Source, req.SourceDatabase},
+		{req.Target, req.TargetDatabase},
+	} {
+		conn, err := s.getConnection(side.id)
+
+		if err != nil {
+			if os.IsNotExist(err) {
+				writeError(w, http.StatusNotFound, "connection not found: "+side.id)
+				return
+			}
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if conn.SQL == nil {
+			writeError(w, http.StatusBadRequest, "connection is not a SQL connection: "+side.id)
+			return
+		}
+
+		if conn.SQL.Driver == "mysql" {
+			ignoreSchema = true
+		}
+
+		tables, err := s.loadSchema(ctx, conn, side.database)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusBadRequest, err)
+			return
+		}
+
+		schemas[i] = tables
+	}
+
+	result := diffSchemas(schemas[0], schemas[1], ignoreSchema)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// diffSchemas compares target against source. Tables are matched by schema (unless
+// ignoreSchema is set) and name, columns by name, both case-insensitively; types are
+// compared as reported by the databases, ignoring case.
+func diffSchemas(source, target []SchemaTable, ignoreSchema bool) SchemaDiffResponse {
+	result := SchemaDiffResponse{
+		MissingTables: make([]SchemaTableRef, 0),
+		ExtraTables:   make([]SchemaTableRef, 0),
+
+		Columns: make([]SchemaColumnDiff, 0),
+	}
+
+	targetTables := make(map[string]SchemaTable, len(target))
+
+	for _, t := range target {
+		targetTables[schemaTableKey(t, ignoreSchema)] = t
+	}
+
+	sourceTables := make(map[string]bool, len(source))
+
+	for _, st := range source {
+		key := schemaTableKey(st, ignoreSchema)
+		sourceTables[key] = true
+
+		tt, ok := targetTables[key]
+
+		if !ok {
+			result.MissingTables = append(result.MissingTables, SchemaTableRef{Schema: st.Schema, Name: st.Name})
+			continue
+		}
+
+		result.Columns = append(result.Columns, diffColumns(st, tt)...)
+	}
+
+	for _, tt := range target {
+		if !sourceTables[schemaTableKey(tt, ignoreSchema)] {
+			result.ExtraTables = append(result.ExtraTables, SchemaTableRef{Schema: tt.Schema, Name: tt.Name})
+		}
+	}
+
+	sortTableRefs(result.MissingTables)
+	sortTableRefs(result.ExtraTables)
+
+	return result
+}
+
+func diffColumns(source, target SchemaTable) []SchemaColumnDiff {
+	var diffs []SchemaColumnDiff
+
+	targetColumns := make(map[string]SchemaColumn, len(target.Columns))
+
+	for _, c := range target.Columns {
+		targetColumns[strings.ToLower(c.Name)] = c
+	}
+
+	sourceColumns := make(map[string]bool, len(source.Columns))
+
+	for _, sc := range source.Columns {
+		key := strings.ToLower(sc.Name)
+		sourceColumns[key] = true
+
+		tc, ok := targetColumns[key]
+
+		if !ok {
+			diffs = append(diffs, SchemaColumnDiff{Schema: source.Schema, Table: source.Name, Column: sc.Name, Kind: "missing", SourceType: sc.Type})
+			continue
+		}
+
+		if !strings.EqualFold(strings.TrimSpace(sc.Type), strings.TrimSpace(tc.Type)) {
+			diffs = append(diffs, SchemaColumnDiff{Schema: source.Schema, Table: source.Name, Column: sc.Name, Kind: "type", SourceType: sc.Type, TargetType: tc.Type})
+		}
+	}
+
+	for _, tc := range target.Columns {
+		if !sourceColumns[strings.ToLower(tc.Name)] {
+			diffs = append(diffs, SchemaColumnDiff{Schema: target.Schema, Table: target.Name, Column: tc.Name, Kind: "extra", TargetType: tc.Type})
+		}
+	}
+
+	return diffs
+}
+
+func schemaTableKey(t SchemaTable, ignoreSchema bool) string {
+	if ignoreSchema {
+		return strings.ToLower(t.Name)
+	}
+
+	return strings.ToLower(t.Schema) + "." + strings.ToLower(t.Name)
+}
+
+func sortTableRefs(refs []SchemaTableRef) {
+	slices.SortFunc(refs, func(a, b SchemaTableRef) int {
+		return cmp.Or(cmp.Compare(a.Schema, b.Schema), cmp.Compare(a.Name, b.Name))
+	})
+}
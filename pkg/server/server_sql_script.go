@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Error policies of scripts run statement by statement (SQLRequest.OnError)
+const (
+	// onErrorStop runs the script in a transaction that is rolled back entirely at the first failure
+	onErrorStop = "stop"
+
+	// onErrorContinue runs every statement on its own and skips failing ones
+	onErrorContinue = "continue"
+
+	// onErrorRollbackStatement runs the script in a transaction with a savepoint
+	// before each statement; failing statements are rolled back to their savepoint,
+	// the others are committed at the end
+	onErrorRollbackStatement = "rollbackStatement"
+)
+
+// scriptSavepoint names the savepoint set before each statement
+const scriptSavepoint = "granite_statement"
+
+// StatementResult reports the outcome of a statement of a script
+type StatementResult struct {
+	Index int `json:"index"` // position of the statement in the script, from 0
+
+	// Status is "ok" (applied), "failed", "rolledBack" (executed, but undone
+	// because the script was aborted) or "skipped" (not run after a failure)
+	Status string `json:"status"`
+
+	RowsAffected int64  `json:"rowsAffected,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// checkScriptPolicy verifies that a script can be run with an error policy on a driver
+func checkScriptPolicy(driver, onError string, params []any) error {
+	switch onError {
+	case "":
+		return nil
+
+	case onErrorContinue:
+
+	case onErrorStop, onErrorRollbackStatement:
+		if driver == "trino" {
+			return fmt.Errorf("onError %s requires transactions, which are not supported for driver %s", onError, driver)
+		}
+
+	default:
+		return fmt.Errorf("onError must be %q, %q or %q", onErrorStop, onErrorContinue, onErrorRollbackStatement)
+	}
+
+	// statements run one by one, so parameters can't be matched to their placeholders
+	if len(params) > 0 {
+		return errors.New("params are not supported with onError")
+	}
+
+	return nil
+}
+
+// runScript executes the statements of a script one by one according to an error
+// policy and reports the outcome of each. Statements that implicitly commit (e.g.
+// DDL on MySQL or Oracle) cannot be rolled back.
+func runScript(ctx context.Context, session sqlSession, driver, onError string, statements []string) ([]StatementResult, error) {
+	results := make([]StatementResult, len(statements))
+
+	for i := range results {
+		results[i] = StatementResult{Index: i, Status: "skipped"}
+	}
+
+	if onError == onErrorContinue {
+		for i, stmt := range statements {
+			results[i].RowsAffected, results[i].Status, results[i].Error = execStatement(ctx, session, stmt)
+		}
+
+		return results, nil
+	}
+
+	tx, err := session.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	save, rollback, release := savepointStatements(driver)
+
+	for i, stmt := range statements {
+		if onError == onErrorRollbackStatement {
+			if _, err := tx.ExecContext(ctx, save); err != nil {
+				return nil, fmt.Errorf("failed to set savepoint: %w", err)
+			}
+		}
+
+		results[i].RowsAffected, results[i].Status, results[i].Error = execStatement(ctx, tx, stmt)
+
+		if results[i].Status == "ok" {
+			if onError == onErrorRollbackStatement && release != "" {
+				if _, err := tx.ExecContext(ctx, release); err != nil {
+					return nil, fmt.Errorf("failed to release savepoint: %w", err)
+				}
+			}
+
+			continue
+		}
+
+		if onError == onErrorStop {
+			for j := range i {
+				results[j].Status = "rolledBack"
+			}
+
+			return results, nil
+		}
+
+		if _, err := tx.ExecContext(ctx, rollback); err != nil {
+			return nil, fmt.Errorf("failed to roll back to savepoint: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// execer is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// execStatement runs a statement and returns its affected rows, status and error message
+func execStatement(ctx context.Context, db execer, stmt string) (int64, string, string) {
+	result, err := db.ExecContext(ctx, stmt)
+
+	if err != nil {
+		return 0, "failed", err.Error()
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected, "ok", ""
+}
+
+// savepointStatements returns the statements to set, roll back to and release
+// the script savepoint; release is empty where savepoints cannot be released
+func savepointStatements(driver string) (string, string, string) {
+	switch driver {
+	case "sqlserver":
+		return "SAVE TRANSACTION " + scriptSavepoint, "ROLLBACK TRANSACTION " + scriptSavepoint, ""
+
+	case "oracle":
+		return "SAVEPOINT " + scriptSavepoint, "ROLLBACK TO SAVEPOINT " + scriptSavepoint, ""
+
+	default:
+		return "SAVEPOINT " + scriptSavepoint, "ROLLBACK TO SAVEPOINT " + scriptSavepoint, "RELEASE SAVEPOINT " + scriptSavepoint
+	}
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+)
+
+// SessionsRequest contains parameters for listing database sessions
+type SessionsRequest struct {
+	Database string `json:"database,omitempty"` // Optional: specify which database to connect to
+}
+
+// KillSessionRequest identifies a session to terminate
+type KillSessionRequest struct {
+	ID       string `json:"id"`
+	Database string `json:"database,omitempty"` // Optional: specify which database to connect to
+
+	Confirm bool `json:"confirm,omitempty"` // Confirms terminating a session on production connections
+}
+
+// SQLSession describes an active database session
+type SQLSession struct {
+	ID        string `json:"id"`
+	User      string `json:"user,omitempty"`
+	Database  string `json:"database,omitempty"`
+	Client    string `json:"client,omitempty"`
+	State     string `json:"state,omitempty"`
+	StartedAt string `json:"startedAt,omitempty"` // Start of the current query or, if unknown, of the session
+	Query     string `json:"query,omitempty"`
+}
+
+// POST /sql/{connection}/sessions - List active sessions on the database server
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req SessionsRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	sessions, err := listSessions(ctx, db, conn.SQL.Driver)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// POST /sql/{connection}/sessions/kill - Terminate a session by id
+func (s *Server) handleKillSession(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req KillSessionRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if req.ID == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	// Terminating sessions is subject to the statement policy as KILL, whatever
+	// statement the driver actually uses
+	if err := checkStatementPolicy(conn.SQL, "KILL"); err != nil {
+		writeErrorFrom(w, http.StatusForbidden, err)
+		return
+	}
+
+	if conn.SQL.ReadOnlyTransactions {
+		writeError(w, http.StatusForbidden, "sessions cannot be terminated on read-only connections")
+		return
+	}
+
+	if err := checkConfirmation(conn, &SQLRequest{Query: "KILL", Confirm: req.Confirm}); err != nil {
+		writeErrorFrom(w, http.StatusPreconditionRequired, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if err := killSession(ctx, db, conn.SQL.Driver, req.ID); err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listSessions reads the active client sessions from the driver's catalog views.
+// Every query returns (id, user, database, client, state, started, query).
+func listSessions(ctx context.Context, db *sql.DB, driver string) ([]SQLSession, error) {
+	var query string
+
+	switch driver {
+	case "postgres", "pgx":
+		query = `
+			SELECT pid::text, usename, datname, client_addr::text, state,
+			       to_char(COALESCE(query_start, backend_start) AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'), query
+			FROM pg_stat_activity
+			WHERE backend_type = 'client backend'
+			ORDER BY pid`
+
+	case "mysql":
+		query = `
+			SELECT CAST(ID AS CHAR), USER, DB, HOST, COALESCE(STATE, COMMAND),
+			       DATE_FORMAT(UTC_TIMESTAMP() - INTERVAL TIME SECOND, '%Y-%m-%dT%H:%i:%sZ'), INFO
+			FROM information_schema.PROCESSLIST
+			ORDER BY ID`
+
+	case "sqlserver":
+		query = `
+			SELECT CAST(s.session_id AS varchar(10)), s.login_name, DB_NAME(s.database_id), s.host_name, s.status,
+			       CONVERT(varchar(33), COALESCE(r.start_time, s.login_time), 126), t.text
+			FROM sys.dm_exec_sessions s
+			LEFT JOIN sys.dm_exec_requests r ON r.session_id = s.session_id
+			OUTER APPLY sys.dm_exec_sql_text(r.sql_handle) t
+			WHERE s.is_user_process = 1
+			ORDER BY s.session_id`
+
+	case "oracle":
+		query = `
+			SELECT s.SID || ',' || s.SERIAL#, s.USERNAME, s.SCHEMANAME, s.MACHINE, s.STATUS,
+			       TO_CHAR(COALESCE(s.SQL_EXEC_START, s.LOGON_TIME), 'YYYY-MM-DD"T"HH24:MI:SS'), q.SQL_TEXT
+			FROM V$SESSION s
+			LEFT JOIN V$SQL q ON q.SQL_ID = s.SQL_ID AND q.CHILD_NUMBER = s.SQL_CHILD_NUMBER
+			WHERE s.TYPE = 'USER'
+			ORDER BY s.SID`
+
+	default:
+		return nil, fmt.Errorf("sessions are not supported for driver %q", driver)
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := make([]SQLSession, 0)
+
+	for rows.Next() {
+		var id, user, database, client, state, started, text sql.NullString
+
+		if err := rows.Scan(&id, &user, &database, &client, &state, &started, &text); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, SQLSession{
+			ID:        id.String,
+			User:      user.String,
+			Database:  database.String,
+			Client:    client.String,
+			State:     state.String,
+			StartedAt: started.String,
+			Query:     text.String,
+		})
+	}
+
+	return sessions, rows.Err()
+}
+
+var (
+	numericSessionID = regexp.MustCompile(`^[0-9]+$`)
+	oracleSessionID  = regexp.MustCompile(`^[0-9]+,[0-9]+$`)
+)
+
+// killSession terminates a session. Ids are validated before being inlined,
+// as KILL statements do not accept bind parameters.
+func killSession(ctx context.Context, db *sql.DB, driver, id string) error {
+	switch driver {
+	case "postgres", "pgx":
+		if !numericSessionID.MatchString(id) {
+			return errors.New("invalid session id")
+		}
+
+		var ok bool
+
+		if err := db.QueryRowContext(ctx, "SELECT pg_terminate_backend("+id+")").Scan(&ok); err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("session not found")
+		}
+
+		return nil
+
+	case "mysql", "sqlserver":
+		if !numericSessionID.MatchString(id) {
+			return errors.New("invalid session id")
+		}
+
+		_, err := db.ExecContext(ctx, "KILL "+id)
+		return err
+
+	case "oracle":
+		if !oracleSessionID.MatchString(id) {
+			return errors.New("invalid session id, expected \"sid,serial#\"")
+		}
+
+		_, err := db.ExecContext(ctx, "ALTER SYSTEM KILL SESSION '"+id+"' IMMEDIATE")
+		return err
+	}
+
+	return fmt.Errorf("sessions are not supported for driver %q", driver)
+}
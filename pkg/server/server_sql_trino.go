@@ -0,0 +1,189 @@
+package server
+
+import (
+	"strings"
+)
+
+// trinoType is a parsed Trino type signature, e.g. ROW(ID BIGINT, TAGS ARRAY(VARCHAR))
+type trinoType struct {
+	Name string // lower-case base type, e.g. "row", "array", "map" or "varchar"
+
+	// Args are the row fields, the array element type or the map key and value types
+	Args []trinoField
+}
+
+// trinoField is a type argument; only row fields have a name
+type trinoField struct {
+	Name string
+	Type trinoType
+}
+
+// trinoValue maps a Trino ROW, ARRAY or MAP value for JSON encoding. The driver
+// returns rows as positional slices; named rows become objects keyed by field name.
+func trinoValue(val any, typeName string) any {
+	return convertTrinoValue(val, parseTrinoType(typeName))
+}
+
+func convertTrinoValue(val any, t trinoType) any {
+	switch v := val.(type) {
+	case []any:
+		switch t.Name {
+		case "array":
+			if len(t.Args) != 1 {
+				return v
+			}
+
+			result := make([]any, len(v))
+
+			for i, element := range v {
+				result[i] = convertTrinoValue(element, t.Args[0].Type)
+			}
+
+			return result
+
+		case "row":
+			if len(t.Args) != len(v) {
+				return v
+			}
+
+			named := true
+
+			for _, field := range t.Args {
+				if field.Name == "" {
+					named = false
+				}
+			}
+
+			if !named {
+				result := make([]any, len(v))
+
+				for i, element := range v {
+					result[i] = convertTrinoValue(element, t.Args[i].Type)
+				}
+
+				return result
+			}
+
+			result := make(map[string]any, len(v))
+
+			for i, element := range v {
+				result[t.Args[i].Name] = convertTrinoValue(element, t.Args[i].Type)
+			}
+
+			return result
+		}
+
+	case map[string]any:
+		if t.Name != "map" || len(t.Args) != 2 {
+			return v
+		}
+
+		result := make(map[string]any, len(v))
+
+		for key, element := range v {
+			result[key] = convertTrinoValue(element, t.Args[1].Type)
+		}
+
+		return result
+	}
+
+	return val
+}
+
+// parseTrinoType parses a type signature as reported by the driver. Trino
+// identifiers are case-insensitive, so names are returned in lower case.
+func parseTrinoType(signature string) trinoType {
+	signature = strings.TrimSpace(signature)
+
+	open := strings.IndexByte(signature, '(')
+
+	if open < 0 || !strings.HasSuffix(signature, ")") {
+		return trinoType{Name: strings.ToLower(signature)}
+	}
+
+	t := trinoType{
+		Name: strings.ToLower(strings.TrimSpace(signature[:open])),
+	}
+
+	if t.Name != "row" && t.Name != "array" && t.Name != "map" {
+		return t
+	}
+
+	for _, arg := range splitTrinoArgs(signature[open+1 : len(signature)-1]) {
+		var field trinoField
+
+		if t.Name == "row" {
+			field.Name, arg = cutTrinoFieldName(arg)
+		}
+
+		field.Type = parseTrinoType(arg)
+		t.Args = append(t.Args, field)
+	}
+
+	return t
+}
+
+// splitTrinoArgs splits type arguments at top-level commas
+func splitTrinoArgs(text string) []string {
+	var args []string
+
+	depth := 0
+	quoted := false
+	start := 0
+
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '"':
+			quoted = !quoted
+
+		case quoted:
+
+		case c == '(':
+			depth++
+
+		case c == ')':
+			depth--
+
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(args, strings.TrimSpace(text[start:]))
+}
+
+// cutTrinoFieldName splits a row field into its (optional) name and type
+func cutTrinoFieldName(field string) (string, string) {
+	if rest, ok := strings.CutPrefix(field, `"`); ok {
+		end := strings.Index(rest, `"`)
+
+		for end >= 0 && strings.HasPrefix(rest[end+1:], `"`) {
+			next := strings.Index(rest[end+2:], `"`)
+
+			if next < 0 {
+				end = -1
+				break
+			}
+
+			end += 2 + next
+		}
+
+		if end < 0 {
+			return "", field
+		}
+
+		name := strings.ReplaceAll(rest[:end], `""`, `"`)
+		return strings.ToLower(name), strings.TrimSpace(rest[end+1:])
+	}
+
+	name, typ, ok := strings.Cut(field, " ")
+
+	// anonymous fields only consist of a type, which may contain spaces itself
+	// (e.g. "TIME WITH TIME ZONE" or "TIMESTAMP(3) WITH TIME ZONE")
+	if !ok || strings.Contains(name, "(") || strings.HasPrefix(strings.ToUpper(typ), "WITH ") {
+		return "", field
+	}
+
+	return strings.ToLower(name), strings.TrimSpace(typ)
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+
+	"github.com/adrianliechti/granite/pkg/tunnel"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// openSQL opens a database handle, through an SSH tunnel if the connection has one.
+// The tunnel lives as long as the handle and is closed with it.
+func openSQL(ctx context.Context, cfg *SQLConfig, dsn string) (*sql.DB, error) {
+	if cfg.SSH == nil {
+		return sql.Open(cfg.Driver, dsn)
+	}
+
+	dsn, t, err := openTunnel(ctx, cfg, dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := openConnector(cfg.Driver, dsn)
+
+	if err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	return sql.OpenDB(&tunnelConnector{Connector: connector, tunnel: t}), nil
+}
+
+// openTunnel starts an SSH tunnel to the database host of a DSN and returns
+// the DSN rewritten to connect to the local end of the tunnel
+func openTunnel(ctx context.Context, cfg *SQLConfig, dsn string) (string, *tunnel.Tunnel, error) {
+	target, err := dsnAddress(cfg.Driver, dsn)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	t, err := tunnel.New(ctx, *cfg.SSH, target)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	dsn, err = dsnWithAddress(cfg.Driver, dsn, t.Addr())
+
+	if err != nil {
+		t.Close()
+		return "", nil, err
+	}
+
+	return dsn, t, nil
+}
+
+// openConnector returns a connector for a registered driver and DSN
+func openConnector(name, dsn string) (driver.Connector, error) {
+	// the driver registry is only exposed through sql.Open, which does not connect yet
+	db, err := sql.Open(name, dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := db.Driver()
+	db.Close()
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+
+	return dsnConnector{dsn: dsn, driver: d}, nil
+}
+
+// tunnelConnector opens connections through an SSH tunnel; sql.DB.Close closes the tunnel
+type tunnelConnector struct {
+	driver.Connector
+
+	tunnel *tunnel.Tunnel
+}
+
+func (c *tunnelConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		closer.Close()
+	}
+
+	return c.tunnel.Close()
+}
+
+// dsnConnector adapts drivers that do not implement driver.DriverContext
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// dsnAddress returns the host:port a DSN connects to. Tunnels require the URL form
+// of the DSN (MySQL: user:pass@tcp(host:port)/db).
+func dsnAddress(driver, dsn string) (string, error) {
+	if driver == "mysql" {
+		cfg, err := mysql.ParseDSN(dsn)
+
+		if err != nil {
+			return "", err
+		}
+
+		if cfg.Net != "tcp" {
+			return "", fmt.Errorf("ssh tunnels require a tcp address, not %q", cfg.Net)
+		}
+
+		return cfg.Addr, nil
+	}
+
+	u, err := tunnelURL(driver, dsn)
+
+	if err != nil {
+		return "", err
+	}
+
+	if port := u.Port(); port != "" {
+		return u.Host, nil
+	}
+
+	return net.JoinHostPort(u.Hostname(), defaultPort(driver, u.Scheme)), nil
+}
+
+// dsnWithAddress returns the DSN connecting to addr instead. The database sees
+// connections from the SSH server and TLS host name checks see the local address.
+func dsnWithAddress(driver, dsn, addr string) (string, error) {
+	if driver == "mysql" {
+		cfg, err := mysql.ParseDSN(dsn)
+
+		if err != nil {
+			return "", err
+		}
+
+		cfg.Addr = addr
+		return cfg.FormatDSN(), nil
+	}
+
+	u, err := tunnelURL(driver, dsn)
+
+	if err != nil {
+		return "", err
+	}
+
+	u.Host = addr
+	return u.String(), nil
+}
+
+func tunnelURL(driver, dsn string) (*url.URL, error) {
+	switch driver {
+	case "postgres", "pgx", "sqlserver", "oracle", "trino":
+	default:
+		return nil, fmt.Errorf("ssh tunnels are not supported for driver %q", driver)
+	}
+
+	u, err := url.Parse(dsn)
+
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("ssh tunnels require a URL DSN (e.g. %s://user:pass@host:port/...)", driver)
+	}
+
+	// named instances are resolved through the SQL Server Browser over UDP
+	if driver == "sqlserver" && u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("ssh tunnels require a port instead of a named instance")
+	}
+
+	return u, nil
+}
+
+func defaultPort(driver, scheme string) string {
+	switch driver {
+	case "postgres", "pgx":
+		return "5432"
+	case "sqlserver":
+		return "1433"
+	case "oracle":
+		return "1521"
+	}
+
+	if scheme == "https" {
+		return "443"
+	}
+
+	return "80"
+}
@@ -0,0 +1,262 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// resultOptions controls how query results are read and converted
+type resultOptions struct {
+	Format string // "objects" (default) or "arrays"
+
+	Offset int
+	Limit  int
+
+	// Columns projects the result to these columns (empty = all)
+	Columns []string
+
+	// Location converts time values into a time zone (nil keeps them as returned)
+	Location *time.Location
+
+	// DecimalsAsStrings returns exact numeric columns as strings instead of floats
+	DecimalsAsStrings bool
+
+	// Mask replaces the non-null values of matching columns with maskedValue
+	Mask *columnMask
+}
+
+// rowScanner scans the rows of a result set and converts their values for
+// JSON encoding, reusing its scan buffers across rows
+type rowScanner struct {
+	typeNames []string
+	opts      resultOptions
+
+	values   []any
+	pointers []any
+	decimals []sql.NullString
+	masked   []bool
+}
+
+func newRowScanner(columns, typeNames []string, opts resultOptions) *rowScanner {
+	s := &rowScanner{
+		typeNames: typeNames,
+		opts:      opts,
+
+		values:   make([]any, len(typeNames)),
+		pointers: make([]any, len(typeNames)),
+		decimals: make([]sql.NullString, len(typeNames)),
+		masked:   make([]bool, len(typeNames)),
+	}
+
+	for i := range s.values {
+		s.masked[i] = opts.Mask.matches(columns[i])
+
+		if opts.DecimalsAsStrings && isDecimalType(typeNames[i]) {
+			s.pointers[i] = &s.decimals[i]
+			continue
+		}
+
+		s.pointers[i] = &s.values[i]
+	}
+
+	return s
+}
+
+// scan reads the current row. The returned slice is reused by the next call,
+// so callers must copy the values they keep.
+func (s *rowScanner) scan(rows *sql.Rows) ([]any, error) {
+	if err := rows.Scan(s.pointers...); err != nil {
+		return nil, err
+	}
+
+	for i, val := range s.values {
+		// Decimals are scanned into their own buffer, values[i] still holds the
+		// previous row's result for them
+		_, decimal := s.pointers[i].(*sql.NullString)
+
+		if decimal {
+			val = nil
+
+			if s.decimals[i].Valid {
+				val = s.decimals[i].String
+			}
+		}
+
+		if s.masked[i] && val != nil {
+			val = maskedValue
+		}
+
+		if s.masked[i] || decimal {
+			s.values[i] = val
+			continue
+		}
+
+		if t, ok := val.(time.Time); ok && s.opts.Location != nil {
+			s.values[i] = t.In(s.opts.Location)
+			continue
+		}
+
+		s.values[i] = jsonValue(val, s.typeNames[i])
+	}
+
+	return s.values, nil
+}
+
+// rowCapacity is the initial capacity of a result slice, sized from the row limit
+func rowCapacity(limit int) int {
+	if limit <= 0 || limit > 1024 {
+		return 1024
+	}
+
+	return limit
+}
+
+// isDecimalType reports whether a database type holds exact numeric values
+// that may lose precision as float64
+func isDecimalType(typeName string) bool {
+	for _, prefix := range []string{"DECIMAL", "NUMERIC", "NUMBER", "MONEY", "SMALLMONEY"} {
+		if strings.HasPrefix(typeName, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// columnTypeNames returns the database type names of the n columns of the
+// current result set, left empty where the driver does not report them
+func columnTypeNames(rows *sql.Rows, n int) []string {
+	names := make([]string, n)
+
+	types, err := rows.ColumnTypes()
+
+	if err != nil {
+		return names
+	}
+
+	for i, t := range types {
+		if i < n {
+			names[i] = strings.ToUpper(t.DatabaseTypeName())
+		}
+	}
+
+	return names
+}
+
+// jsonValue converts a scanned value for JSON encoding. Bytes become strings,
+// JSON columns are embedded as JSON, Postgres arrays become JSON arrays and
+// Trino rows with named fields become JSON objects.
+func jsonValue(val any, typeName string) any {
+	var text string
+
+	switch v := val.(type) {
+	case []byte:
+		text = string(v)
+
+	case string:
+		text = v
+
+	case []any, map[string]any:
+		// Trino returns ROW and ARRAY values as slices and MAP values as maps
+		return trinoValue(v, typeName)
+
+	default:
+		return val
+	}
+
+	switch {
+	case typeName == "JSON" || typeName == "JSONB":
+		if json.Valid([]byte(text)) {
+			return json.RawMessage(text)
+		}
+
+	case strings.HasPrefix(typeName, "_") && strings.HasPrefix(text, "{"):
+		if array, ok := parsePostgresArray(text); ok {
+			return array
+		}
+	}
+
+	return text
+}
+
+// parsePostgresArray parses the text representation of a Postgres array
+// (e.g. {1,2,NULL} or {{"a b",c},{d,e}}) into nested slices of strings
+func parsePostgresArray(text string) ([]any, bool) {
+	array, rest, ok := parsePostgresArrayLevel(text)
+	return array, ok && rest == ""
+}
+
+func parsePostgresArrayLevel(text string) ([]any, string, bool) {
+	if !strings.HasPrefix(text, "{") {
+		return nil, text, false
+	}
+
+	text = text[1:]
+	result := make([]any, 0)
+
+	if strings.HasPrefix(text, "}") {
+		return result, text[1:], true
+	}
+
+	for {
+		switch {
+		case strings.HasPrefix(text, "{"):
+			nested, rest, ok := parsePostgresArrayLevel(text)
+
+			if !ok {
+				return nil, text, false
+			}
+
+			result = append(result, nested)
+			text = rest
+
+		case strings.HasPrefix(text, `"`):
+			var element strings.Builder
+
+			i := 1
+
+			for ; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+				}
+
+				element.WriteByte(text[i])
+			}
+
+			if i >= len(text) {
+				return nil, text, false
+			}
+
+			result = append(result, element.String())
+			text = text[i+1:]
+
+		default:
+			end := strings.IndexAny(text, ",}")
+
+			if end < 0 {
+				return nil, text, false
+			}
+
+			if element := text[:end]; element == "NULL" {
+				result = append(result, nil)
+			} else {
+				result = append(result, element)
+			}
+
+			text = text[end:]
+		}
+
+		switch {
+		case strings.HasPrefix(text, ","):
+			text = text[1:]
+
+		case strings.HasPrefix(text, "}"):
+			return result, text[1:], true
+
+		default:
+			return nil, text, false
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestParsePostgresArray(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   []any
+		wantOK bool
+	}{
+		{"{}", []any{}, true},
+		{"{1,2,3}", []any{"1", "2", "3"}, true},
+		{"{a,NULL,\"NULL\"}", []any{"a", nil, "NULL"}, true},
+		{`{"a b","c,d","e\"f","g\\h"}`, []any{"a b", "c,d", `e"f`, `g\h`}, true},
+		{`{{1,2},{"x",NULL}}`, []any{[]any{"1", "2"}, []any{"x", nil}}, true},
+		{"{{}}", []any{[]any{}}, true},
+		{"", nil, false},
+		{"1,2", nil, false},
+		{"{1,2", nil, false},
+		{`{"a}`, nil, false},
+		{"{1,2}x", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parsePostgresArray(tt.text)
+
+		if ok != tt.wantOK {
+			t.Errorf("parsePostgresArray(%q) ok = %v, want %v", tt.text, ok, tt.wantOK)
+			continue
+		}
+
+		if ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePostgresArray(%q) = %#v, want %#v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRowScanner(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`CREATE TABLE t (id INTEGER, amount DECIMAL(10,2), secret DECIMAL(10,2), name TEXT);
+		INSERT INTO t VALUES (1, 12.5, 99.5, 'a'), (2, NULL, NULL, NULL), (3, 7, 1, 'c')`); err != nil {
+		t.Fatal(err)
+	}
+
+	mask, err := newColumnMask([]string{"secret", "/^na/"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts resultOptions
+		want [][]any
+	}{
+		{"defaults", resultOptions{}, [][]any{
+			{int64(1), 12.5, 99.5, "a"},
+			{int64(2), nil, nil, nil},
+			{int64(3), int64(7), int64(1), "c"},
+		}},
+		{"decimals as strings", resultOptions{DecimalsAsStrings: true}, [][]any{
+			{int64(1), "12.5", "99.5", "a"},
+			{int64(2), nil, nil, nil},
+			{int64(3), "7", "1", "c"},
+		}},
+		{"masked", resultOptions{Mask: mask}, [][]any{
+			{int64(1), 12.5, maskedValue, maskedValue},
+			{int64(2), nil, nil, nil},
+			{int64(3), int64(7), maskedValue, maskedValue},
+		}},
+		{"masked decimals", resultOptions{DecimalsAsStrings: true, Mask: mask}, [][]any{
+			{int64(1), "12.5", maskedValue, maskedValue},
+			{int64(2), nil, nil, nil},
+			{int64(3), "7", maskedValue, maskedValue},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := db.Query("SELECT id, amount, secret, name FROM t ORDER BY id")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rows.Close()
+
+			columns, _ := rows.Columns()
+			types, _ := rows.ColumnTypes()
+
+			typeNames := make([]string, len(types))
+
+			for i, ct := range types {
+				typeNames[i] = ct.DatabaseTypeName()
+			}
+
+			scanner := newRowScanner(columns, typeNames, tt.opts)
+
+			var got [][]any
+
+			for rows.Next() {
+				values, err := scanner.scan(rows)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got = append(got, append([]any(nil), values...))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scan() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// ValidateResponse reports whether a query could be prepared
+type ValidateResponse struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+
+	// Location of the error in the query (1-based), where the driver reports one
+	Position int `json:"position,omitempty"` // character offset
+	Line     int `json:"line,omitempty"`
+	Column   int `json:"column,omitempty"`
+}
+
+// POST /sql/{connection}/validate - Check a query for syntax errors by preparing it without executing
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.SQL == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a SQL connection")
+		return
+	}
+
+	var req SQLRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if err := s.checkQueryLimits(conn.SQL.Driver, req.Query); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	db, err := s.openDatabase(ctx, conn, req.Database)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	defer db.Close()
+
+	if timeout := s.queryTimeout(conn, &req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(validateQuery(ctx, db, conn.SQL.Driver, req.Query))
+}
+
+// validateQuery prepares every statement of a query separately (drivers
+// rarely accept several at once) and reports the first failure
+func validateQuery(ctx context.Context, db *sql.DB, driver, query string) ValidateResponse {
+	cursor := 0
+
+	for _, stmt := range splitStatements(driver, query) {
+		offset := cursor + strings.Index(query[cursor:], stmt)
+		cursor = offset + len(stmt)
+
+		prepared, err := db.PrepareContext(ctx, stmt)
+
+		if err != nil {
+			resp := ValidateResponse{Error: err.Error()}
+
+			if position := errorPosition(err); position > 0 {
+				resp.Position = utf8.RuneCountInString(query[:offset]) + position
+				resp.Line, resp.Column = lineColumn(query, resp.Position)
+			}
+
+			return resp
+		}
+
+		prepared.Close()
+	}
+
+	return ValidateResponse{Valid: true}
+}
+
+// errorPosition returns the 1-based character position of a syntax error, if the driver reports one
+func errorPosition(err error) int {
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) {
+		position, _ := strconv.Atoi(pqErr.Position)
+		return position
+	}
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+		return int(pgErr.Position)
+	}
+
+	return 0
+}
+
+// lineColumn converts a 1-based character position into a 1-based line and column
+func lineColumn(text string, position int) (int, int) {
+	line, column := 1, 1
+
+	for i, r := range []rune(text) {
+		if i+1 >= position {
+			break
+		}
+
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return line, column
+}
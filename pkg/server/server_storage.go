@@ -2,6 +2,10 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
 
 	"github.com/adrianliechti/granite/pkg/storage"
 	"github.com/adrianliechti/granite/pkg/storage/azblob"
@@ -14,7 +18,20 @@ type ListObjectsRequest struct {
 	Prefix            string `json:"prefix"`
 	Delimiter         string `json:"delimiter"`
 	MaxKeys           int    `json:"maxKeys"`
-	ContinuationToken string `json:"continuationToken"`
+	ContinuationToken string `json:"continuationToken"` // opaque cursor from a previous page
+
+	// FolderSizes aggregates object count and size per returned prefix (costs extra list calls)
+	FolderSizes bool `json:"folderSizes,omitempty"`
+
+	// FoldersOnly returns all prefixes below Prefix without any objects (e.g. for lazy tree views)
+	FoldersOnly bool `json:"foldersOnly,omitempty"`
+
+	// Optional: only return objects modified after / before these times (RFC 3339)
+	ModifiedAfter  string `json:"modifiedAfter,omitempty"`
+	ModifiedBefore string `json:"modifiedBefore,omitempty"`
+
+	// IncludeDeleted also lists soft-deleted objects (Azure only)
+	IncludeDeleted bool `json:"includeDeleted,omitempty"`
 }
 
 // ObjectRequest contains parameters for object operations
@@ -22,6 +39,13 @@ type ObjectRequest struct {
 	Container string `json:"container"`
 	Key       string `json:"key"`
 	ExpiresIn int    `json:"expiresIn,omitempty"`
+
+	// Optional: override the Content-Type and Content-Disposition of presigned downloads
+	ResponseContentType        string `json:"responseContentType,omitempty"`
+	ResponseContentDisposition string `json:"responseContentDisposition,omitempty"`
+
+	// Optional: file name for presigned downloads (sets an attachment Content-Disposition)
+	DownloadFilename string `json:"downloadFilename,omitempty"`
 }
 
 // CreateContainerRequest contains parameters for creating a container
@@ -29,6 +53,16 @@ type CreateContainerRequest struct {
 	Name string `json:"name"`
 }
 
+// ContainerExistsRequest contains parameters for probing a container
+type ContainerExistsRequest struct {
+	Name string `json:"name"`
+}
+
+// ContainerExistsResponse reports whether a container exists
+type ContainerExistsResponse struct {
+	Exists bool `json:"exists"`
+}
+
 // PresignedURLResponse contains a presigned URL
 type PresignedURLResponse struct {
 	URL string `json:"url"`
@@ -48,6 +82,38 @@ func newStorageProviderFromConnection(ctx context.Context, conn *Connection) (st
 	}
 }
 
+// storageKind returns the provider ID of a storage connection ("s3" or "azure-blob")
+func storageKind(conn *Connection) string {
+	if conn.AzureBlob != nil {
+		return "azure-blob"
+	}
+
+	return "s3"
+}
+
+// encodeCursor wraps a provider continuation token (an S3 continuation token or
+// an Azure marker) into an opaque cursor, so clients can page all providers alike
+func encodeCursor(conn *Connection, token string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(storageKind(conn) + ":" + token))
+}
+
+// decodeCursor returns the provider continuation token of a cursor created by encodeCursor
+func decodeCursor(conn *Connection, cursor string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return "", errors.New("invalid continuation token")
+	}
+
+	kind, token, ok := strings.Cut(string(data), ":")
+
+	if !ok || kind != storageKind(conn) {
+		return "", errors.New("invalid continuation token")
+	}
+
+	return token, nil
+}
+
 // ErrUnsupportedProvider is returned when an unsupported storage provider is specified
 var ErrUnsupportedProvider = &Error{Message: "unsupported storage provider"}
 
@@ -59,3 +125,36 @@ type Error struct {
 func (e *Error) Error() string {
 	return e.Message
 }
+
+// acquireTransfer reserves a storage transfer slot, responding with 429 if all are in use.
+// The returned release function must be called once the transfer is done.
+func (s *Server) acquireTransfer(w http.ResponseWriter) (func(), bool) {
+	if s.transfers == nil {
+		return func() {}, true
+	}
+
+	select {
+	case s.transfers <- struct{}{}:
+		return func() { <-s.transfers }, true
+
+	default:
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "too many concurrent transfers, try again later")
+		return nil, false
+	}
+}
+
+// listPageSize applies the configured default and maximum to a requested listing page size
+func (s *Server) listPageSize(requested int) int {
+	size := requested
+
+	if size <= 0 {
+		size = s.config.ListPageSize
+	}
+
+	if limit := s.config.MaxListPageSize; limit > 0 && size > limit {
+		size = limit
+	}
+
+	return size
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+)
+
+// ChecksumRequest contains parameters for computing an object checksum
+type ChecksumRequest struct {
+	Container string `json:"container"`
+	Key       string `json:"key"`
+	Algorithm string `json:"algorithm,omitempty"` // "md5" (default) or "sha256"
+
+	// Compute always streams the object, even if the provider stores a checksum
+	Compute bool `json:"compute,omitempty"`
+}
+
+// ChecksumResponse contains the checksum of an object
+type ChecksumResponse struct {
+	Algorithm string `json:"algorithm"`
+	Checksum  string `json:"checksum"` // hex encoded
+	Source    string `json:"source"`   // "stored" or "computed"
+}
+
+// POST /storage/{connection}/object/checksum - Get the MD5 or SHA-256 checksum of an object
+func (s *Server) handleStorageObjectChecksum(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req ChecksumRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Container == "" || req.Key == "" {
+		writeError(w, http.StatusBadRequest, "Container and key are required")
+		return
+	}
+
+	if req.Algorithm == "" {
+		req.Algorithm = "md5"
+	}
+
+	var h hash.Hash
+
+	switch req.Algorithm {
+	case "md5":
+		h = md5.New()
+
+	case "sha256":
+		h = sha256.New()
+
+	default:
+		writeError(w, http.StatusBadRequest, "algorithm must be \"md5\" or \"sha256\"")
+		return
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Prefer checksums stored by the provider over reading the whole object
+	if !req.Compute {
+		details, err := provider.GetObjectDetails(ctx, req.Container, req.Key)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		stored := details.ContentMD5
+
+		if req.Algorithm == "sha256" {
+			stored = details.ChecksumSHA256
+		}
+
+		if stored != nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(ChecksumResponse{Algorithm: req.Algorithm, Checksum: *stored, Source: "stored"})
+			return
+		}
+	}
+
+	release, ok := s.acquireTransfer(w)
+
+	if !ok {
+		return
+	}
+
+	defer release()
+
+	object, err := provider.GetObject(ctx, req.Container, req.Key, storage.GetObjectOptions{})
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	defer object.Body.Close()
+
+	n, err := io.Copy(h, object.Body)
+
+	s.metrics.downloadBytes.Add(n)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read object: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChecksumResponse{Algorithm: req.Algorithm, Checksum: hex.EncodeToString(h.Sum(nil)), Source: "computed"})
+}
@@ -2,8 +2,11 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
+
+	"github.com/adrianliechti/granite/pkg/storage"
 )
 
 // POST /storage/{connection}/containers - List containers
@@ -16,7 +19,7 @@ func (s *Server) handleStorageContainers(w http.ResponseWriter, r *http.Request)
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -29,14 +32,14 @@ func (s *Server) handleStorageContainers(w http.ResponseWriter, r *http.Request)
 	provider, err := newStorageProviderFromConnection(ctx, conn)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
 	containers, err := provider.ListContainers(ctx)
 
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -54,7 +57,7 @@ func (s *Server) handleStorageCreateContainer(w http.ResponseWriter, r *http.Req
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -65,8 +68,10 @@ func (s *Server) handleStorageCreateContainer(w http.ResponseWriter, r *http.Req
 
 	var req CreateContainerRequest
 
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeDecodeError(w, err, "Invalid request body")
 		return
 	}
 
@@ -79,14 +84,71 @@ func (s *Server) handleStorageCreateContainer(w http.ResponseWriter, r *http.Req
 	provider, err := newStorageProviderFromConnection(ctx, conn)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
 	if err := provider.CreateContainer(ctx, req.Name); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
+
+// POST /storage/{connection}/containers/exists - Check that a container exists and is accessible
+func (s *Server) handleStorageContainerExists(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req ContainerExistsRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Container name is required")
+		return
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	exists, err := provider.ContainerExists(ctx, req.Name)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrAccessDenied) {
+			writeErrorFrom(w, http.StatusForbidden, err)
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ContainerExistsResponse{Exists: exists})
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+
+	"github.com/adrianliechti/granite/pkg/storage"
 )
 
 // DeleteObjectRequest contains parameters for deleting objects
@@ -22,7 +24,7 @@ func (s *Server) handleStorageDeleteObject(w http.ResponseWriter, r *http.Reques
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -33,8 +35,10 @@ func (s *Server) handleStorageDeleteObject(w http.ResponseWriter, r *http.Reques
 
 	var req DeleteObjectRequest
 
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeDecodeError(w, err, "Invalid request body")
 		return
 	}
 
@@ -52,18 +56,42 @@ func (s *Server) handleStorageDeleteObject(w http.ResponseWriter, r *http.Reques
 	provider, err := newStorageProviderFromConnection(ctx, conn)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Objects are moved to the trash, unless it is disabled or they are in the trash already
+	var remove, trash []string
+
+	for _, key := range req.Keys {
+		if conn.Trash && !storage.IsTrashKey(key) {
+			trash = append(trash, key)
+		} else {
+			remove = append(remove, key)
+		}
+	}
+
+	trashed, err := storage.TrashObjects(ctx, provider, req.Container, trash)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	// Use DeleteObjects for efficiency (handles single or multiple keys)
-	if err := provider.DeleteObjects(ctx, req.Container, req.Keys); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := provider.DeleteObjects(ctx, req.Container, remove); err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]any{
+	resp := map[string]any{
 		"deleted": len(req.Keys),
-	})
+	}
+
+	if len(trashed) > 0 {
+		resp["trashed"] = trashed
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
 }
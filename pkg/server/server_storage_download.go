@@ -0,0 +1,172 @@
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+)
+
+// DownloadZipRequest contains parameters for downloading a folder as zip archive
+type DownloadZipRequest struct {
+	Container string `json:"container"`
+	Prefix    string `json:"prefix"`
+}
+
+// POST /storage/{connection}/download-zip - Stream all objects below a prefix as zip archive
+func (s *Server) handleStorageDownloadZip(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req DownloadZipRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Container == "" {
+		writeError(w, http.StatusBadRequest, "container is required")
+		return
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	release, ok := s.acquireTransfer(w)
+
+	if !ok {
+		return
+	}
+
+	defer release()
+
+	// Fetch the first page before committing to a zip response, so that
+	// listing errors can still be reported as JSON
+	opts := storage.ListObjectsOptions{
+		Prefix: req.Prefix,
+	}
+
+	page, err := provider.ListObjects(ctx, req.Container, opts)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	name := path.Base(strings.TrimSuffix(req.Prefix, "/"))
+
+	if req.Prefix == "" || name == "." || name == "/" {
+		name = req.Container
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".zip"}))
+
+	// Entries are named relative to the folder containing the prefix
+	base := req.Prefix[:strings.LastIndex(req.Prefix, "/")+1]
+
+	archive := zip.NewWriter(w)
+
+	for {
+		for _, obj := range page.Objects {
+			if obj.IsFolder {
+				continue
+			}
+
+			entry, ok := zipEntryName(obj.Key, base)
+
+			if !ok {
+				slog.Warn("skipping object without a valid zip entry name", "container", req.Container, "key", obj.Key)
+				continue
+			}
+
+			n, err := writeZipEntry(ctx, archive, provider, req.Container, obj.Key, entry)
+
+			s.metrics.downloadBytes.Add(n)
+
+			if err != nil {
+				// Headers are already sent; abort the stream so the client sees a broken archive
+				slog.Error("failed to write zip entry", "container", req.Container, "key", obj.Key, "error", err)
+				return
+			}
+		}
+
+		if !page.HasMore {
+			break
+		}
+
+		opts.ContinuationToken = *page.ContinuationToken
+
+		if page, err = provider.ListObjects(ctx, req.Container, opts); err != nil {
+			slog.Error("failed to list objects for zip", "container", req.Container, "error", err)
+			return
+		}
+	}
+
+	archive.Close()
+}
+
+// zipEntryName returns the archive path of an object relative to base. Keys
+// are free-form, so "..", absolute paths and backslashes are resolved to keep
+// entries from being extracted outside the target folder.
+func zipEntryName(key, base string) (string, bool) {
+	name := strings.ReplaceAll(strings.TrimPrefix(key, base), "\\", "/")
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	return name, name != ""
+}
+
+// writeZipEntry streams a single object into the archive and returns the number of bytes read
+func writeZipEntry(ctx context.Context, archive *zip.Writer, provider storage.Provider, container, key, name string) (int64, error) {
+	content, err := provider.GetObject(ctx, container, key, storage.GetObjectOptions{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer content.Body.Close()
+
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: content.LastModified,
+	}
+
+	entry, err := archive.CreateHeader(header)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return io.Copy(entry, content.Body)
+}
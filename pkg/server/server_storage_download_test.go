@@ -0,0 +1,29 @@
+package server
+
+import "testing"
+
+func TestZipEntryName(t *testing.T) {
+	tests := []struct {
+		key    string
+		base   string
+		want   string
+		wantOK bool
+	}{
+		{"photos/a.jpg", "", "photos/a.jpg", true},
+		{"photos/2024/a.jpg", "photos/", "2024/a.jpg", true},
+		{"photos/../../etc/passwd", "photos/", "etc/passwd", true},
+		{"/etc/passwd", "", "etc/passwd", true},
+		{`..\..\windows\a.dll`, "", "windows/a.dll", true},
+		{"a//b/./c", "", "a/b/c", true},
+		{"photos/..", "photos/", "", false},
+		{"/", "", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := zipEntryName(tt.key, tt.base)
+
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("zipEntryName(%q, %q) = %q, %v, want %q, %v", tt.key, tt.base, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// multipartUploadTTL bounds how long an unfinished upload is kept after its last part
+	multipartUploadTTL = 24 * time.Hour
+
+	// maxUploadParts is the highest part number of a multipart upload (the S3 limit)
+	maxUploadParts = 10000
+
+	// maxUploadPartSize bounds the size of a part, Azure buffers parts in memory
+	maxUploadPartSize = 64 << 20
+)
+
+// MultipartUploadRequest starts a multipart upload of an object
+type MultipartUploadRequest struct {
+	Container string `json:"container"`
+	Key       string `json:"key"`
+
+	ContentType string            `json:"contentType,omitempty"` // Optional: derived from the key's extension if empty
+	Metadata    map[string]string `json:"metadata,omitempty"`
+
+	IfMatch string `json:"ifMatch,omitempty"` // Optional: only overwrite the object if it still has this ETag
+	ACL     string `json:"acl,omitempty"`
+
+	ServerSideEncryption string `json:"serverSideEncryption,omitempty"`
+	SSEKMSKeyID          string `json:"sseKmsKeyId,omitempty"`
+}
+
+// MultipartUploadStatus describes a multipart upload and the parts uploaded so far
+type MultipartUploadStatus struct {
+	UploadID string `json:"uploadId"`
+
+	Container string `json:"container"`
+	Key       string `json:"key"`
+
+	MaxParts    int   `json:"maxParts"`
+	MaxPartSize int64 `json:"maxPartSize"`
+
+	Parts []MultipartUploadPart `json:"parts"`
+}
+
+// MultipartUploadPart is an uploaded part of a multipart upload
+type MultipartUploadPart struct {
+	PartNumber int    `json:"partNumber"`
+	Size       int64  `json:"size"`
+	ETag       string `json:"etag"`
+}
+
+// uploadSessions tracks the multipart uploads in progress by their session token.
+// Sessions live in memory only, uploads are lost when the server restarts. Expired
+// uploads are aborted, but the parts of uploads lost on a restart remain until a
+// bucket lifecycle rule (e.g. AbortIncompleteMultipartUpload on S3) removes them.
+type uploadSessions struct {
+	mu      sync.Mutex
+	entries map[string]*uploadSession
+}
+
+type uploadSession struct {
+	connID string
+	upload *storage.MultipartUpload
+
+	parts   map[int]MultipartUploadPart
+	expires time.Time
+
+	// reserved is the size of the parts being uploaded
+	reserved int64
+}
+
+func newUploadSessions() *uploadSessions {
+	return &uploadSessions{
+		entries: make(map[string]*uploadSession),
+	}
+}
+
+// add registers an upload and returns its session token, along with the
+// sessions that have expired since and whose uploads must be aborted
+func (u *uploadSessions) add(connID string, upload *storage.MultipartUpload) (string, []*uploadSession) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+
+	var expired []*uploadSession
+
+	for token, session := range u.entries {
+		if now.After(session.expires) {
+			delete(u.entries, token)
+			expired = append(expired, session)
+		}
+	}
+
+	token := uuid.NewString()
+
+	u.entries[token] = &uploadSession{
+		connID: normalizeConnectionID(connID),
+		upload: upload,
+
+		parts:   make(map[int]MultipartUploadPart),
+		expires: now.Add(multipartUploadTTL),
+	}
+
+	return token, expired
+}
+
+// get returns the upload of a session and its parts, ordered by part number
+func (u *uploadSessions) get(connID, token string) (*storage.MultipartUpload, []MultipartUploadPart, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	session, ok := u.entries[token]
+
+	if !ok || session.connID != normalizeConnectionID(connID) || time.Now().After(session.expires) {
+		return nil, nil, false
+	}
+
+	parts := make([]MultipartUploadPart, 0, len(session.parts))
+
+	for _, part := range session.parts {
+		parts = append(parts, part)
+	}
+
+	slices.SortFunc(parts, func(a, b MultipartUploadPart) int {
+		return cmp.Compare(a.PartNumber, b.PartNumber)
+	})
+
+	return session.upload, parts, true
+}
+
+// reservePart reserves the size of a part about to be uploaded, so that parts
+// uploaded in parallel cannot exceed the limit together. The limit applies to
+// the whole object, a replaced part no longer counts.
+func (u *uploadSessions) reservePart(token string, partNumber int, size, limit int64) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	session, ok := u.entries[token]
+
+	if !ok {
+		return false
+	}
+
+	total := session.reserved
+
+	for _, part := range session.parts {
+		if part.PartNumber != partNumber {
+			total += part.Size
+		}
+	}
+
+	if total+size > limit {
+		return false
+	}
+
+	session.reserved += size
+	return true
+}
+
+// releasePart releases the reservation of a part whose upload failed
+func (u *uploadSessions) releasePart(token string, size int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if session, ok := u.entries[token]; ok {
+		session.reserved -= size
+	}
+}
+
+// setPart records an uploaded part, replacing an earlier upload of the same
+// part number, and releases its reservation
+func (u *uploadSessions) setPart(token string, part MultipartUploadPart) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	session, ok := u.entries[token]
+
+	if !ok {
+		return
+	}
+
+	session.parts[part.PartNumber] = part
+	session.reserved -= part.Size
+	session.expires = time.Now().Add(multipartUploadTTL)
+}
+
+func (u *uploadSessions) remove(token string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	delete(u.entries, token)
+}
+
+// POST /storage/{connection}/multipart - Start a multipart upload and return its session token
+func (s *Server) handleStorageCreateMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req MultipartUploadRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	if req.Container == "" || req.Key == "" {
+		writeError(w, http.StatusBadRequest, "container and key are required")
+		return
+	}
+
+	if !validObjectACL(req.ACL) {
+		writeError(w, http.StatusBadRequest, "invalid acl: "+req.ACL)
+		return
+	}
+
+	contentType := req.ContentType
+
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(req.Key))
+	}
+
+	opts := storage.UploadOptions{
+		ContentType: contentType,
+		Metadata:    req.Metadata,
+
+		IfMatch: req.IfMatch,
+		ACL:     req.ACL,
+
+		ServerSideEncryption: req.ServerSideEncryption,
+		SSEKMSKeyID:          req.SSEKMSKeyID,
+	}
+
+	ctx := r.Context()
+	storageProvider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	upload, err := storageProvider.CreateMultipartUpload(ctx, req.Container, req.Key, opts)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	token, expired := s.uploads.add(connID, upload)
+
+	if len(expired) > 0 {
+		go s.abortUploads(expired)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(MultipartUploadStatus{
+		UploadID: token,
+
+		Container: upload.Container,
+		Key:       upload.Key,
+
+		MaxParts:    maxUploadParts,
+		MaxPartSize: maxUploadPartSize,
+
+		Parts: make([]MultipartUploadPart, 0),
+	})
+}
+
+// GET /storage/{connection}/multipart/{upload} - List the parts uploaded so far, to resume an upload
+func (s *Server) handleStorageMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("upload")
+
+	upload, parts, ok := s.uploads.get(r.PathValue("connection"), token)
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MultipartUploadStatus{
+		UploadID: token,
+
+		Container: upload.Container,
+		Key:       upload.Key,
+
+		MaxParts:    maxUploadParts,
+		MaxPartSize: maxUploadPartSize,
+
+		Parts: parts,
+	})
+}
+
+// PUT /storage/{connection}/multipart/{upload}/{part} - Upload the raw request body as a part.
+// Parts can be uploaded in parallel and uploading a part number again replaces the part.
+func (s *Server) handleStorageUploadPart(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+	token := r.PathValue("upload")
+
+	partNumber, err := strconv.Atoi(r.PathValue("part"))
+
+	if err != nil || partNumber < 1 || partNumber > maxUploadParts {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("part number must be between 1 and %d", maxUploadParts))
+		return
+	}
+
+	upload, _, ok := s.uploads.get(connID, token)
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	if r.ContentLength < 0 {
+		writeError(w, http.StatusLengthRequired, "Content-Length is required")
+		return
+	}
+
+	if r.ContentLength > maxUploadPartSize {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("part exceeds %d bytes", maxUploadPartSize))
+		return
+	}
+
+	if !s.uploads.reservePart(token, partNumber, r.ContentLength, s.config.MaxUploadSize) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("upload exceeds %d bytes", s.config.MaxUploadSize))
+		return
+	}
+
+	reserved := true
+
+	defer func() {
+		if reserved {
+			s.uploads.releasePart(token, r.ContentLength)
+		}
+	}()
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx := r.Context()
+	storageProvider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	release, ok := s.acquireTransfer(w)
+
+	if !ok {
+		return
+	}
+
+	defer release()
+
+	body := &countingReader{
+		Reader: http.MaxBytesReader(w, r.Body, r.ContentLength),
+		count:  &s.metrics.uploadBytes,
+	}
+
+	etag, err := storageProvider.UploadPart(ctx, upload, partNumber, body, r.ContentLength)
+
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	part := MultipartUploadPart{
+		PartNumber: partNumber,
+		Size:       r.ContentLength,
+		ETag:       etag,
+	}
+
+	s.uploads.setPart(token, part)
+	reserved = false
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(part)
+}
+
+// POST /storage/{connection}/multipart/{upload}/complete - Assemble the object from the uploaded parts
+func (s *Server) handleStorageCompleteMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+	token := r.PathValue("upload")
+
+	upload, parts, ok := s.uploads.get(connID, token)
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	if len(parts) == 0 {
+		writeError(w, http.StatusBadRequest, "no parts have been uploaded")
+		return
+	}
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx := r.Context()
+	storageProvider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	completed := make([]storage.CompletedPart, 0, len(parts))
+
+	for _, part := range parts {
+		completed = append(completed, storage.CompletedPart{
+			PartNumber: part.PartNumber,
+			ETag:       part.ETag,
+		})
+	}
+
+	if err := storageProvider.CompleteMultipartUpload(ctx, upload, completed); err != nil {
+		if errors.Is(err, storage.ErrPreconditionFailed) {
+			writeError(w, http.StatusPreconditionFailed, "object has been modified")
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.uploads.remove(token)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"key": upload.Key,
+	})
+}
+
+// DELETE /storage/{connection}/multipart/{upload} - Abort a multipart upload and discard its parts
+func (s *Server) handleStorageAbortMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+	token := r.PathValue("upload")
+
+	upload, _, ok := s.uploads.get(connID, token)
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx := r.Context()
+	storageProvider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := storageProvider.AbortMultipartUpload(ctx, upload); err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.uploads.remove(token)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// abortUploads aborts the multipart uploads of expired sessions, so that their
+// parts are not kept (and billed) as incomplete uploads
+func (s *Server) abortUploads(sessions []*uploadSession) {
+	for _, session := range sessions {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+
+		if err := s.abortUpload(ctx, session); err != nil {
+			slog.Warn("failed to abort expired multipart upload", "connection", session.connID, "container", session.upload.Container, "key", session.upload.Key, "error", err)
+		}
+
+		cancel()
+	}
+}
+
+func (s *Server) abortUpload(ctx context.Context, session *uploadSession) error {
+	conn, err := s.getConnection(session.connID)
+
+	if err != nil {
+		return err
+	}
+
+	storageProvider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		return err
+	}
+
+	return storageProvider.AbortMultipartUpload(ctx, session.upload)
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+)
+
+func TestUploadSessionsReservePart(t *testing.T) {
+	sessions := newUploadSessions()
+	token, _ := sessions.add("conn", &storage.MultipartUpload{Container: "c", Key: "k"})
+
+	steps := []struct {
+		name string
+		run  func() bool
+		want bool
+	}{
+		{"first part", func() bool { return sessions.reservePart(token, 1, 60, 100) }, true},
+		{"parallel part over the limit", func() bool { return sessions.reservePart(token, 2, 50, 100) }, false},
+		{"parallel part within the limit", func() bool { return sessions.reservePart(token, 2, 40, 100) }, true},
+		{"uploaded parts", func() bool {
+			sessions.setPart(token, MultipartUploadPart{PartNumber: 1, Size: 60})
+			sessions.setPart(token, MultipartUploadPart{PartNumber: 2, Size: 40})
+			return true
+		}, true},
+		{"replaced part", func() bool { return sessions.reservePart(token, 2, 40, 100) }, true},
+		{"failed upload", func() bool { sessions.releasePart(token, 40); return true }, true},
+		{"new part over the limit", func() bool { return sessions.reservePart(token, 3, 1, 100) }, false},
+		{"unknown session", func() bool { return sessions.reservePart("other", 1, 1, 100) }, false},
+	}
+
+	for _, step := range steps {
+		if got := step.run(); got != step.want {
+			t.Fatalf("%s: got %v, want %v", step.name, got, step.want)
+		}
+	}
+}
@@ -1,9 +1,17 @@
 package server
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/adrianliechti/granite/pkg/storage"
 )
@@ -18,7 +26,7 @@ func (s *Server) handleStorageObjects(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -29,8 +37,10 @@ func (s *Server) handleStorageObjects(w http.ResponseWriter, r *http.Request) {
 
 	var req ListObjectsRequest
 
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeDecodeError(w, err, "Invalid request body")
 		return
 	}
 
@@ -43,24 +53,77 @@ func (s *Server) handleStorageObjects(w http.ResponseWriter, r *http.Request) {
 	provider, err := newStorageProviderFromConnection(ctx, conn)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
 	opts := storage.ListObjectsOptions{
-		Prefix:            req.Prefix,
-		Delimiter:         req.Delimiter,
-		MaxKeys:           req.MaxKeys,
-		ContinuationToken: req.ContinuationToken,
+		Prefix:    req.Prefix,
+		Delimiter: req.Delimiter,
+		MaxKeys:   s.listPageSize(req.MaxKeys),
+
+		IncludeDeleted: req.IncludeDeleted,
+	}
+
+	if req.ContinuationToken != "" {
+		if opts.ContinuationToken, err = decodeCursor(conn, req.ContinuationToken); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
-	result, err := provider.ListObjects(ctx, req.Container, opts)
+	if opts.ModifiedAfter, err = parseOptionalTime(req.ModifiedAfter); err != nil {
+		writeError(w, http.StatusBadRequest, "modifiedAfter must be an RFC 3339 timestamp")
+		return
+	}
 
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if opts.ModifiedBefore, err = parseOptionalTime(req.ModifiedBefore); err != nil {
+		writeError(w, http.StatusBadRequest, "modifiedBefore must be an RFC 3339 timestamp")
 		return
 	}
 
+	var result *storage.ListObjectsResult
+
+	if req.FoldersOnly {
+		delimiter := req.Delimiter
+
+		if delimiter == "" {
+			delimiter = "/"
+		}
+
+		prefixes, err := storage.ListPrefixes(ctx, provider, req.Container, req.Prefix, delimiter)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		result = storage.NewListObjectsResult([]storage.Object{}, prefixes, nil)
+	} else {
+		result, err = provider.ListObjects(ctx, req.Container, opts)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if result.ContinuationToken != nil {
+		cursor := encodeCursor(conn, *result.ContinuationToken)
+		result.ContinuationToken = &cursor
+	}
+
+	if req.FolderSizes && len(result.Prefixes) > 0 {
+		sizes, err := storage.ComputePrefixSizes(ctx, provider, req.Container, result.Prefixes, 10000)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		result.PrefixSizes = sizes
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
@@ -75,7 +138,7 @@ func (s *Server) handleStorageObjectDetails(w http.ResponseWriter, r *http.Reque
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -86,8 +149,10 @@ func (s *Server) handleStorageObjectDetails(w http.ResponseWriter, r *http.Reque
 
 	var req ObjectRequest
 
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeDecodeError(w, err, "Invalid request body")
 		return
 	}
 
@@ -100,14 +165,14 @@ func (s *Server) handleStorageObjectDetails(w http.ResponseWriter, r *http.Reque
 	provider, err := newStorageProviderFromConnection(ctx, conn)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
 	result, err := provider.GetObjectDetails(ctx, req.Container, req.Key)
 
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -125,7 +190,7 @@ func (s *Server) handleStoragePresignedURL(w http.ResponseWriter, r *http.Reques
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -136,8 +201,10 @@ func (s *Server) handleStoragePresignedURL(w http.ResponseWriter, r *http.Reques
 
 	var req ObjectRequest
 
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeDecodeError(w, err, "Invalid request body")
 		return
 	}
 
@@ -150,7 +217,7 @@ func (s *Server) handleStoragePresignedURL(w http.ResponseWriter, r *http.Reques
 	provider, err := newStorageProviderFromConnection(ctx, conn)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
@@ -160,13 +227,260 @@ func (s *Server) handleStoragePresignedURL(w http.ResponseWriter, r *http.Reques
 		expiresIn = 3600 // Default 1 hour
 	}
 
-	url, err := provider.GetPresignedURL(ctx, req.Container, req.Key, expiresIn)
+	opts := storage.PresignOptions{
+		ExpiresIn: expiresIn,
+
+		ResponseContentType:        req.ResponseContentType,
+		ResponseContentDisposition: req.ResponseContentDisposition,
+	}
+
+	// Save the download under a clean name instead of the full key
+	if opts.ResponseContentDisposition == "" && req.DownloadFilename != "" {
+		name := storage.GetObjectName(strings.ReplaceAll(req.DownloadFilename, "\\", "/"))
+		opts.ResponseContentDisposition = mime.FormatMediaType("attachment", map[string]string{"filename": name})
+	}
+
+	url, err := provider.GetPresignedURL(ctx, req.Container, req.Key, opts)
 
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(PresignedURLResponse{URL: url})
 }
+
+// GET /storage/{connection}/object/download?container=X&key=Y - Stream an object.
+// responseContentType and responseContentDisposition override the response headers,
+// decode=true decompresses objects stored with Content-Encoding gzip.
+// A single byte range in the Range header is answered with 206 Partial Content
+// so interrupted downloads can be resumed.
+func (s *Server) handleStorageDownloadObject(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	query := r.URL.Query()
+
+	container := query.Get("container")
+	key := query.Get("key")
+
+	if container == "" || key == "" {
+		writeError(w, http.StatusBadRequest, "Container and key are required")
+		return
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	release, ok := s.acquireTransfer(w)
+
+	if !ok {
+		return
+	}
+
+	defer release()
+
+	decode := query.Get("decode") == "true"
+
+	var byteRange *storage.ByteRange
+	var objectSize int64
+
+	// Ranges refer to the stored bytes, so they are ignored when decompressing
+	if header := r.Header.Get("Range"); header != "" && !decode {
+		details, err := provider.GetObjectDetails(ctx, container, key)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		objectSize = details.Size
+
+		if ifRangeMatches(r.Header.Get("If-Range"), details) {
+			byteRange, err = parseByteRange(header, details.Size)
+
+			if err != nil {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", details.Size))
+				writeError(w, http.StatusRequestedRangeNotSatisfiable, err.Error())
+				return
+			}
+		}
+	}
+
+	object, err := provider.GetObject(ctx, container, key, storage.GetObjectOptions{Range: byteRange})
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	defer object.Body.Close()
+
+	body := io.Reader(object.Body)
+	size := object.Size
+
+	// Optionally decompress gzip-encoded objects, e.g. to preview compressed logs
+	if decode && strings.Contains(strings.ToLower(object.ContentEncoding), "gzip") {
+		reader, err := gzip.NewReader(object.Body)
+
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to decompress object: "+err.Error())
+			return
+		}
+
+		defer reader.Close()
+
+		body = reader
+		size = 0
+	}
+
+	contentType := query.Get("responseContentType")
+
+	if contentType == "" {
+		contentType = object.ContentType
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	disposition := query.Get("responseContentDisposition")
+
+	if disposition == "" {
+		disposition = mime.FormatMediaType("attachment", map[string]string{"filename": storage.GetObjectName(key)})
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", disposition)
+
+	if size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+
+	if !object.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", object.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if !decode {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+
+	if byteRange != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", byteRange.Offset, byteRange.Offset+byteRange.Length-1, objectSize))
+		w.Header().Set("Content-Length", strconv.FormatInt(byteRange.Length, 10))
+		w.WriteHeader(http.StatusPartialContent)
+	}
+
+	n, _ := io.Copy(w, body)
+	s.metrics.downloadBytes.Add(n)
+}
+
+var errRangeNotSatisfiable = errors.New("requested range not satisfiable")
+
+// parseByteRange resolves a Range header ("bytes=0-99", "bytes=100-" or "bytes=-100")
+// against the object size. Malformed headers and multiple ranges return nil, so
+// the whole object is sent; ranges starting beyond the end return errRangeNotSatisfiable.
+func parseByteRange(header string, size int64) (*storage.ByteRange, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+
+	if !ok || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+
+	if !ok {
+		return nil, nil
+	}
+
+	// suffix range: the last n bytes
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+
+		if err != nil || n < 0 {
+			return nil, nil
+		}
+
+		if n == 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+
+		n = min(n, size)
+		return &storage.ByteRange{Offset: size - n, Length: n}, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+
+	end := size - 1
+
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return nil, nil
+		}
+
+		end = min(end, size-1)
+	}
+
+	if start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+
+	return &storage.ByteRange{Offset: start, Length: end - start + 1}, nil
+}
+
+// ifRangeMatches reports whether an If-Range precondition (an ETag or an HTTP date)
+// still matches the object; if not, the whole object is sent instead of the range
+func ifRangeMatches(ifRange string, details *storage.ObjectDetails) bool {
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		// If-Range requires a strong comparison, weak validators never match
+		return details.ETag != nil && !strings.HasPrefix(ifRange, "W/") &&
+			strings.Trim(ifRange, `"`) == strings.Trim(*details.ETag, `"`)
+	}
+
+	since, err := http.ParseTime(ifRange)
+
+	if err != nil {
+		return false
+	}
+
+	modified, err := time.Parse(time.RFC3339, details.LastModified)
+
+	return err == nil && modified.Equal(since)
+}
+
+// parseOptionalTime parses an RFC 3339 timestamp; an empty value yields the zero time
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+)
+
+const (
+	// objectRangeDefaultLength is the number of bytes read unless the request specifies it
+	objectRangeDefaultLength = 64 << 10
+
+	// objectRangeMaxLength bounds the bytes of a single range response
+	objectRangeMaxLength = 1 << 20
+)
+
+// ObjectRangeRequest contains parameters for reading a byte range of an object
+type ObjectRangeRequest struct {
+	Container string `json:"container"`
+	Key       string `json:"key"`
+
+	Offset int64 `json:"offset,omitempty"`
+	Length int64 `json:"length,omitempty"` // Optional: bytes to read (default 64 KiB, max 1 MiB)
+}
+
+// ObjectRangeResponse contains a byte range of an object
+type ObjectRangeResponse struct {
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"` // bytes returned, less than requested at the end of the object
+	Size   int64  `json:"size"`   // total size of the object
+	Data   string `json:"data"`   // base64 encoded
+	EOF    bool   `json:"eof"`    // the range reaches the end of the object
+}
+
+// POST /storage/{connection}/object/range - Read a byte range of an object, e.g. for a hex viewer
+func (s *Server) handleStorageObjectRange(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req ObjectRangeRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Container == "" || req.Key == "" {
+		writeError(w, http.StatusBadRequest, "Container and key are required")
+		return
+	}
+
+	if req.Offset < 0 {
+		writeError(w, http.StatusBadRequest, "offset must not be negative")
+		return
+	}
+
+	if req.Length < 0 || req.Length > objectRangeMaxLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("length must be between 1 and %d", objectRangeMaxLength))
+		return
+	}
+
+	if req.Length == 0 {
+		req.Length = objectRangeDefaultLength
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// The size clamps the range, providers reject ranges beyond the end of an object
+	details, err := provider.GetObjectDetails(ctx, req.Container, req.Key)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := ObjectRangeResponse{
+		Offset: req.Offset,
+		Size:   details.Size,
+		EOF:    true,
+	}
+
+	if req.Offset < details.Size {
+		length := min(req.Length, details.Size-req.Offset)
+
+		object, err := provider.GetObject(ctx, req.Container, req.Key, storage.GetObjectOptions{
+			Range: &storage.ByteRange{Offset: req.Offset, Length: length},
+		})
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		defer object.Body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(object.Body, length))
+
+		s.metrics.downloadBytes.Add(int64(len(data)))
+
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read object: "+err.Error())
+			return
+		}
+
+		resp.Length = int64(len(data))
+		resp.Data = base64.StdEncoding.EncodeToString(data)
+		resp.EOF = req.Offset+resp.Length >= details.Size
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+)
+
+// SearchObjectsRequest contains parameters for finding objects by metadata
+type SearchObjectsRequest struct {
+	Container string `json:"container"`
+	Prefix    string `json:"prefix,omitempty"` // Optional: only search objects below this prefix
+
+	// Metadata the objects must have; keys are matched case-insensitively,
+	// an empty value matches any object that has the key
+	Metadata map[string]string `json:"metadata"`
+
+	MaxObjects int `json:"maxObjects,omitempty"` // Optional: objects to inspect (default 1000, at most 10000)
+}
+
+// SearchObjectsResponse contains the objects matching a metadata search
+type SearchObjectsResponse struct {
+	Objects []storage.ObjectDetails `json:"objects"`
+
+	Scanned   int  `json:"scanned"`             // number of objects inspected
+	Truncated bool `json:"truncated,omitempty"` // more objects were left uninspected
+}
+
+const (
+	searchDefaultObjects = 1000
+	searchMaxObjects     = 10000
+
+	// searchConcurrency bounds the number of concurrent detail requests
+	searchConcurrency = 8
+)
+
+// POST /storage/{connection}/objects/search - Find objects by user metadata.
+// Neither S3 nor Azure filter on metadata server-side, so every object below the
+// prefix costs a detail request; the scan stops after maxObjects objects.
+func (s *Server) handleStorageSearchObjects(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req SearchObjectsRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Container == "" {
+		writeError(w, http.StatusBadRequest, "container is required")
+		return
+	}
+
+	if len(req.Metadata) == 0 {
+		writeError(w, http.StatusBadRequest, "metadata is required")
+		return
+	}
+
+	maxObjects := req.MaxObjects
+
+	if maxObjects <= 0 {
+		maxObjects = searchDefaultObjects
+	}
+
+	maxObjects = min(maxObjects, searchMaxObjects)
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := SearchObjectsResponse{
+		Objects: make([]storage.ObjectDetails, 0),
+	}
+
+	opts := storage.ListObjectsOptions{
+		Prefix: req.Prefix,
+	}
+
+	for {
+		page, err := provider.ListObjects(ctx, req.Container, opts)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		keys := make([]string, 0, len(page.Objects))
+
+		for _, obj := range page.Objects {
+			if !obj.IsFolder {
+				keys = append(keys, obj.Key)
+			}
+		}
+
+		if remaining := maxObjects - resp.Scanned; len(keys) > remaining {
+			keys = keys[:remaining]
+			resp.Truncated = true
+		}
+
+		matches, err := searchObjects(ctx, provider, req.Container, keys, req.Metadata)
+
+		if err != nil {
+			writeErrorFrom(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp.Objects = append(resp.Objects, matches...)
+		resp.Scanned += len(keys)
+
+		if resp.Truncated || !page.HasMore {
+			break
+		}
+
+		if resp.Scanned >= maxObjects {
+			resp.Truncated = true
+			break
+		}
+
+		opts.ContinuationToken = *page.ContinuationToken
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// searchObjects fetches the details of the given objects concurrently and
+// returns those matching the metadata, in the order of keys
+func searchObjects(ctx context.Context, provider storage.Provider, container string, keys []string, metadata map[string]string) ([]storage.ObjectDetails, error) {
+	details := make([]*storage.ObjectDetails, len(keys))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	sem := make(chan struct{}, searchConcurrency)
+
+	for i, key := range keys {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			d, err := provider.GetObjectDetails(ctx, container, key)
+
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+
+			if matchesMetadata(d.Metadata, metadata) {
+				details[i] = d
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	matches := make([]storage.ObjectDetails, 0)
+
+	for _, d := range details {
+		if d != nil {
+			matches = append(matches, *d)
+		}
+	}
+
+	return matches, nil
+}
+
+// matchesMetadata reports whether an object's metadata contains all wanted
+// keys (case-insensitive) with the wanted values (any value if empty)
+func matchesMetadata(metadata, wanted map[string]string) bool {
+	for key, value := range wanted {
+		found := false
+
+		for k, v := range metadata {
+			if strings.EqualFold(k, key) && (value == "" || v == value) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
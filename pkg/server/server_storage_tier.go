@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+)
+
+// SetAccessTierRequest contains parameters for changing an object's access tier
+type SetAccessTierRequest struct {
+	Container string `json:"container"`
+	Key       string `json:"key"`
+	Tier      string `json:"tier"` // "hot", "cool", "cold", "archive" (or an S3 storage class)
+}
+
+// POST /storage/{connection}/object/tier - Change the access tier of an object
+func (s *Server) handleStorageSetAccessTier(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req SetAccessTierRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Container == "" || req.Key == "" {
+		writeError(w, http.StatusBadRequest, "Container and key are required")
+		return
+	}
+
+	if req.Tier == "" {
+		writeError(w, http.StatusBadRequest, "tier is required")
+		return
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := provider.SetAccessTier(ctx, req.Container, req.Key, req.Tier); err != nil {
+		if errors.Is(err, storage.ErrUnsupportedAccessTier) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+)
+
+// RestoreObjectsRequest contains parameters for restoring objects from the trash
+type RestoreObjectsRequest struct {
+	Container string   `json:"container"`
+	Keys      []string `json:"keys"` // Keys of the objects in the trash (below .trash/)
+}
+
+// EmptyTrashRequest contains parameters for emptying the trash of a container
+type EmptyTrashRequest struct {
+	Container string `json:"container"`
+}
+
+// POST /storage/{connection}/trash/restore - Move objects from the trash back to their original keys
+func (s *Server) handleStorageRestoreObjects(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req RestoreObjectsRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Container == "" {
+		writeError(w, http.StatusBadRequest, "container is required")
+		return
+	}
+
+	if len(req.Keys) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one key is required")
+		return
+	}
+
+	for _, key := range req.Keys {
+		if !storage.IsTrashKey(key) {
+			writeError(w, http.StatusBadRequest, key+" is not in the trash")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	restored, err := storage.RestoreObjects(ctx, provider, req.Container, req.Keys)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"restored": restored,
+	})
+}
+
+// POST /storage/{connection}/trash/empty - Permanently delete all objects in the trash
+func (s *Server) handleStorageEmptyTrash(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req EmptyTrashRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Container == "" {
+		writeError(w, http.StatusBadRequest, "container is required")
+		return
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	deleted, err := storage.EmptyTrash(ctx, provider, req.Container)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"deleted": deleted,
+	})
+}
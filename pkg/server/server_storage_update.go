@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/adrianliechti/granite/pkg/storage"
+)
+
+// UpdateObjectRequest contains changes to an object's metadata and HTTP headers.
+// Omitted fields are left unchanged, empty headers are removed.
+type UpdateObjectRequest struct {
+	Container string `json:"container"`
+	Key       string `json:"key"`
+
+	Metadata map[string]string `json:"metadata,omitempty"` // Optional: replaces all user metadata
+
+	CacheControl       *string `json:"cacheControl,omitempty"`       // Optional: e.g. "public, max-age=3600"
+	ContentDisposition *string `json:"contentDisposition,omitempty"` // Optional: e.g. "attachment; filename=\"report.pdf\""
+}
+
+// POST /storage/{connection}/object/update - Change the metadata, Cache-Control and Content-Disposition of an object
+func (s *Server) handleStorageUpdateObject(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	var req UpdateObjectRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	if req.Container == "" || req.Key == "" {
+		writeError(w, http.StatusBadRequest, "Container and key are required")
+		return
+	}
+
+	if req.Metadata == nil && req.CacheControl == nil && req.ContentDisposition == nil {
+		writeError(w, http.StatusBadRequest, "metadata, cacheControl or contentDisposition is required")
+		return
+	}
+
+	ctx := r.Context()
+	provider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	update := storage.ObjectUpdate{
+		Metadata: req.Metadata,
+
+		CacheControl:       req.CacheControl,
+		ContentDisposition: req.ContentDisposition,
+	}
+
+	if err := provider.UpdateObject(ctx, req.Container, req.Key, update); err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	details, err := provider.GetObjectDetails(ctx, req.Container, req.Key)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
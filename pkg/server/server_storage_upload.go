@@ -1,12 +1,22 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianliechti/granite/pkg/storage"
 
 	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
 )
 
 // POST /storage/{connection}/upload - Upload an object to storage
@@ -19,7 +29,7 @@ func (s *Server) handleStorageUploadObject(w http.ResponseWriter, r *http.Reques
 			writeError(w, http.StatusNotFound, "connection not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -28,29 +38,33 @@ func (s *Server) handleStorageUploadObject(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Parse multipart form (32 MB max)
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		writeError(w, http.StatusBadRequest, "Failed to parse multipart form")
+	release, ok := s.acquireTransfer(w)
+
+	if !ok {
 		return
 	}
 
-	// Get upload parameters from form
-	container := r.FormValue("container")
-	objectKey := r.FormValue("key")
+	defer release()
 
-	if container == "" || objectKey == "" {
-		writeError(w, http.StatusBadRequest, "container and key are required")
-		return
-	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxUploadSize)
 
-	ctx := r.Context()
-	storageProvider, err := newStorageProviderFromConnection(ctx, conn)
+	// Parse multipart form (files beyond the memory threshold are spooled to disk)
+	err = r.ParseMultipartForm(s.config.MultipartMemory)
+
+	// Remove the temporary files as soon as the upload is done, also on errors
+	if r.MultipartForm != nil {
+		defer r.MultipartForm.RemoveAll()
+	}
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeDecodeError(w, err, "Failed to parse multipart form")
 		return
 	}
 
+	// Get upload parameters from form
+	container := r.FormValue("container")
+	objectKey := r.FormValue("key")
+
 	// Get the uploaded file
 	file, header, err := r.FormFile("file")
 
@@ -61,11 +75,21 @@ func (s *Server) handleStorageUploadObject(w http.ResponseWriter, r *http.Reques
 
 	defer file.Close()
 
-	// Read file data
-	data, err := io.ReadAll(file)
+	// Expand the key template if no explicit key was given
+	if template := r.FormValue("keyTemplate"); objectKey == "" && template != "" {
+		objectKey = expandKeyTemplate(template, header.Filename, time.Now())
+	}
+
+	if container == "" || objectKey == "" {
+		writeError(w, http.StatusBadRequest, "container and key (or keyTemplate) are required")
+		return
+	}
+
+	ctx := r.Context()
+	storageProvider, err := newStorageProviderFromConnection(ctx, conn)
 
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to read file")
+		writeErrorFrom(w, http.StatusBadRequest, err)
 		return
 	}
 
@@ -77,19 +101,238 @@ func (s *Server) handleStorageUploadObject(w http.ResponseWriter, r *http.Reques
 	}
 
 	if contentType == "" {
-		// Detect from file content
-		mtype := mimetype.Detect(data)
-		contentType = mtype.String()
+		// Detect from the first bytes of the file, then rewind it for the upload
+		head := make([]byte, 512)
+		n, err := io.ReadFull(file, head)
+
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			writeError(w, http.StatusInternalServerError, "Failed to read file")
+			return
+		}
+
+		contentType = mimetype.Detect(head[:n]).String()
+
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to read file")
+			return
+		}
+	}
+
+	opts := storage.UploadOptions{
+		ContentType: contentType,
+
+		// Only overwrite the object if it still has the ETag the client loaded
+		IfMatch: r.FormValue("ifMatch"),
+
+		ACL: r.FormValue("acl"),
+
+		ServerSideEncryption: r.FormValue("serverSideEncryption"),
+		SSEKMSKeyID:          r.FormValue("sseKmsKeyId"),
 	}
 
-	// Upload the object
-	if err := storageProvider.UploadObject(ctx, container, objectKey, data, contentType); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if !validObjectACL(opts.ACL) {
+		writeError(w, http.StatusBadRequest, "invalid acl: "+opts.ACL)
+		return
+	}
+
+	// Optional user metadata as a JSON object of strings
+	if value := r.FormValue("metadata"); value != "" {
+		if err := json.Unmarshal([]byte(value), &opts.Metadata); err != nil {
+			writeError(w, http.StatusBadRequest, "metadata must be a JSON object of strings")
+			return
+		}
+	}
+
+	// Upload the object, streaming the file from memory or its temporary file
+	if err := storageProvider.UploadObject(ctx, container, objectKey, file, header.Size, opts); err != nil {
+		if errors.Is(err, storage.ErrPreconditionFailed) {
+			writeError(w, http.StatusPreconditionFailed, "object has been modified")
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	s.metrics.uploadBytes.Add(header.Size)
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
 		"key": objectKey,
 	})
 }
+
+// PUT /storage/{connection}/object?container=X&key=Y - Upload the raw request body as an object.
+// User metadata can be passed as X-Meta-<Name> headers, the expected ETag as If-Match header or ifMatch parameter
+// and a canned ACL as acl parameter. S3 connections require a Content-Length.
+func (s *Server) handleStoragePutObject(w http.ResponseWriter, r *http.Request) {
+	connID := r.PathValue("connection")
+
+	conn, err := s.getConnection(connID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if conn.AmazonS3 == nil && conn.AzureBlob == nil {
+		writeError(w, http.StatusBadRequest, "connection is not a storage connection")
+		return
+	}
+
+	container := r.URL.Query().Get("container")
+	objectKey := r.URL.Query().Get("key")
+
+	if container == "" || objectKey == "" {
+		writeError(w, http.StatusBadRequest, "container and key are required")
+		return
+	}
+
+	if r.ContentLength > s.config.MaxUploadSize {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", s.config.MaxUploadSize))
+		return
+	}
+
+	// S3 rejects streamed uploads of unknown length (e.g. chunked bodies)
+	if r.ContentLength < 0 && conn.AmazonS3 != nil {
+		writeError(w, http.StatusLengthRequired, "Content-Length is required")
+		return
+	}
+
+	ctx := r.Context()
+	storageProvider, err := newStorageProviderFromConnection(ctx, conn)
+
+	if err != nil {
+		writeErrorFrom(w, http.StatusBadRequest, err)
+		return
+	}
+
+	release, ok := s.acquireTransfer(w)
+
+	if !ok {
+		return
+	}
+
+	defer release()
+
+	body := io.Reader(&countingReader{
+		Reader: http.MaxBytesReader(w, r.Body, s.config.MaxUploadSize),
+		count:  &s.metrics.uploadBytes,
+	})
+
+	contentType := r.Header.Get("Content-Type")
+
+	if contentType == "" {
+		// Detect from the first bytes and replay them ahead of the rest of the body
+		head := make([]byte, 3072)
+		n, err := io.ReadFull(body, head)
+
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			writeDecodeError(w, err, "Failed to read request body")
+			return
+		}
+
+		head = head[:n]
+		contentType = mimetype.Detect(head).String()
+
+		body = io.MultiReader(bytes.NewReader(head), body)
+	}
+
+	opts := storage.UploadOptions{
+		ContentType: contentType,
+		Metadata:    metadataFromHeader(r.Header),
+
+		IfMatch: r.Header.Get("If-Match"),
+
+		ACL: r.URL.Query().Get("acl"),
+	}
+
+	if value := r.URL.Query().Get("ifMatch"); value != "" {
+		opts.IfMatch = value
+	}
+
+	if !validObjectACL(opts.ACL) {
+		writeError(w, http.StatusBadRequest, "invalid acl: "+opts.ACL)
+		return
+	}
+
+	if err := storageProvider.UploadObject(ctx, container, objectKey, body, r.ContentLength, opts); err != nil {
+		var maxBytesErr *http.MaxBytesError
+
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+
+		if errors.Is(err, storage.ErrPreconditionFailed) {
+			writeError(w, http.StatusPreconditionFailed, "object has been modified")
+			return
+		}
+
+		writeErrorFrom(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"key": objectKey,
+	})
+}
+
+// validObjectACL reports whether acl is empty or one of the canned object ACLs
+func validObjectACL(acl string) bool {
+	switch acl {
+	case "", "private", "public-read", "public-read-write", "authenticated-read",
+		"aws-exec-read", "bucket-owner-read", "bucket-owner-full-control":
+		return true
+	}
+
+	return false
+}
+
+// metadataFromHeader collects user metadata from X-Meta-<Name> request headers
+func metadataFromHeader(header http.Header) map[string]string {
+	var metadata map[string]string
+
+	for name, values := range header {
+		key, ok := strings.CutPrefix(name, "X-Meta-")
+
+		if !ok || key == "" || len(values) == 0 {
+			continue
+		}
+
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+
+		metadata[strings.ToLower(key)] = values[0]
+	}
+
+	return metadata
+}
+
+// expandKeyTemplate builds an object key from a template with the placeholders
+// {date} (2006/01/02), {year}, {month}, {day}, {timestamp} (unix seconds),
+// {uuid}, {filename} and {ext} (file extension without the dot)
+func expandKeyTemplate(template, filename string, now time.Time) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	ext := strings.TrimPrefix(path.Ext(filename), ".")
+
+	now = now.UTC()
+
+	replacer := strings.NewReplacer(
+		"{date}", now.Format("2006/01/02"),
+		"{year}", now.Format("2006"),
+		"{month}", now.Format("01"),
+		"{day}", now.Format("02"),
+		"{timestamp}", strconv.FormatInt(now.Unix(), 10),
+		"{uuid}", uuid.NewString(),
+		"{filename}", filename,
+		"{ext}", ext,
+	)
+
+	return replacer.Replace(template)
+}
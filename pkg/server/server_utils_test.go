@@ -0,0 +1,117 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizePlaceholders(t *testing.T) {
+	tests := []struct {
+		name       string
+		driver     string
+		query      string
+		params     []any
+		wantQuery  string
+		wantParams []any
+		wantErr    bool
+	}{
+		{"postgres unchanged", "postgres", "SELECT $1, $2", []any{1, 2}, "SELECT $1, $2", []any{1, 2}, false},
+		{"question marks to postgres", "postgres", "SELECT ?, ?", []any{1, 2}, "SELECT $1, $2", []any{1, 2}, false},
+		{"numbered to sqlserver", "sqlserver", "SELECT $1, :2", []any{1, 2}, "SELECT @p1, @p2", []any{1, 2}, false},
+		{"numbered to oracle", "oracle", "SELECT @p2, $1", []any{1, 2}, "SELECT :2, :1", []any{1, 2}, false},
+		{"reordered for mysql", "mysql", "SELECT $2, $1", []any{1, 2}, "SELECT ?, ?", []any{2, 1}, false},
+		{"repeated for sqlite", "sqlite", "SELECT $1 WHERE a = $1", []any{"x"}, "SELECT ? WHERE a = ?", []any{"x", "x"}, false},
+		{"no placeholders", "mysql", "SELECT 1", []any{}, "SELECT 1", []any{}, false},
+		{"literals and comments", "postgres", "SELECT '?', \"$1\" -- ?\n, ?", []any{1}, "SELECT '?', \"$1\" -- ?\n, $1", []any{1}, false},
+		{"casts", "postgres", "SELECT $1::int, '{}'::jsonb", []any{1}, "SELECT $1::int, '{}'::jsonb", []any{1}, false},
+		{"dollar quotes", "postgres", "SELECT $$?$$, ?", []any{1}, "SELECT $$?$$, $1", []any{1}, false},
+		{"variables", "sqlserver", "DECLARE @x int; SELECT @x, ?", []any{1}, "DECLARE @x int; SELECT @x, @p1", []any{1}, false},
+		{"mixed styles", "postgres", "SELECT ?, $1", []any{1}, "", nil, true},
+		{"missing parameter", "postgres", "SELECT $2", []any{1}, "", nil, true},
+		{"zero placeholder", "mysql", "SELECT $0", []any{1}, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params, err := normalizePlaceholders(tt.driver, tt.query, tt.params)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizePlaceholders(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+
+			if query != tt.wantQuery || !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("normalizePlaceholders(%q) = %q, %v, want %q, %v", tt.query, query, params, tt.wantQuery, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestExpandArrayParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		driver     string
+		query      string
+		params     []any
+		wantQuery  string
+		wantParams []any
+		wantErr    bool
+	}{
+		{"no arrays", "postgres", "SELECT $1", []any{1}, "SELECT $1", []any{1}, false},
+		{"in list", "postgres", "SELECT * FROM t WHERE id IN ($1) AND a = $2", []any{[]any{1, 2, 3}, "x"},
+			"SELECT * FROM t WHERE id IN ($1, $2, $3) AND a = $4", []any{1, 2, 3, "x"}, false},
+		{"repeated list", "mysql", "SELECT ? IN (?)", []any{"a", []any{"b", "c"}},
+			"SELECT ? IN (?, ?)", []any{"a", "b", "c"}, false},
+		{"sqlserver", "sqlserver", "SELECT 1 WHERE a IN (@p1)", []any{[]any{1, 2}},
+			"SELECT 1 WHERE a IN (@p1, @p2)", []any{1, 2}, false},
+		{"empty list", "postgres", "SELECT 1 WHERE a IN ($1) OR b = $2", []any{[]any{}, 1},
+			"SELECT 1 WHERE a IN (NULL) OR b = $1", []any{1}, false},
+		{"postgres any", "postgres", "SELECT 1 WHERE a = any ( $1 )", []any{[]any{float64(1), float64(2.5)}},
+			"SELECT 1 WHERE a = any ( $1 )", []any{`{"1","2.5"}`}, false},
+		{"postgres any strings", "pgx", "SELECT 1 WHERE a = ANY($1)", []any{[]any{`a"b`, `c\d`, nil}},
+			"SELECT 1 WHERE a = ANY($1)", []any{`{"a\"b","c\\d",NULL}`}, false},
+		{"any on mysql", "mysql", "SELECT 1 WHERE a = ANY(?)", []any{[]any{1, 2}},
+			"SELECT 1 WHERE a = ANY(?, ?)", []any{1, 2}, false},
+		{"missing parameter", "postgres", "SELECT $1, $2", []any{[]any{1}}, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params, err := expandArrayParams(tt.driver, tt.query, tt.params)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandArrayParams(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+
+			if query != tt.wantQuery || !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("expandArrayParams(%q) = %q, %v, want %q, %v", tt.query, query, params, tt.wantQuery, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestMaskDSN(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		dsn    string
+		want   string
+	}{
+		{"postgres url", "postgres", "postgres://u:p%40ss@h:5432/db?sslmode=disable", "postgres://u:***@h:5432/db?sslmode=disable"},
+		{"postgres url without password", "postgres", "postgres://u@h/db", "postgres://u@h/db"},
+		{"sqlserver url", "sqlserver", "sqlserver://sa:secret@h:1433?database=x&password=y", "sqlserver://sa:***@h:1433?database=x&password=***"},
+		{"postgres keywords", "postgres", `host=h user=u password='a b\' c' dbname=x sslpassword=zz`, "host=h user=u password=*** dbname=x sslpassword=***"},
+		{"mysql", "mysql", "root:p@ss:w@tcp(h:3306)/db?parseTime=true", "root:***@tcp(h:3306)/db?parseTime=true"},
+		{"mysql without password", "mysql", "root@tcp(h:3306)/db", "root@tcp(h:3306)/db"},
+		{"sqlserver ado", "sqlserver", "server=h;user id=sa;Password=Secret1;database=x", "server=h;user id=sa;Password=***;database=x"},
+		{"invalid url", "postgres", "postgres://u:100%@h/db", "postgres://***@h/db"},
+		{"sqlite", "sqlite", "file:test.db?cache=shared", "file:test.db?cache=shared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskDSN(tt.driver, tt.dsn); got != tt.want {
+				t.Errorf("maskDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,31 @@
 package azblob
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/adrianliechti/granite/pkg/storage"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
 	azcontainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/google/uuid"
 )
 
 // Config contains Azure Blob Storage connection configuration
@@ -21,6 +34,29 @@ type Config struct {
 	AccountKey       string `json:"accountKey,omitempty"`
 	SASToken         string `json:"sasToken,omitempty"`
 	ConnectionString string `json:"connectionString,omitempty"`
+
+	// ManagedIdentityClientID selects a user-assigned managed identity
+	ManagedIdentityClientID string `json:"managedIdentityClientId,omitempty"`
+
+	// Optional: custom endpoints, e.g. "http://127.0.0.1:10000/devstoreaccount1" for Azurite
+	// or "core.chinacloudapi.cn" for sovereign clouds (defaults to "core.windows.net")
+	ServiceURL     string `json:"serviceUrl,omitempty"`
+	EndpointSuffix string `json:"endpointSuffix,omitempty"`
+}
+
+// serviceURL returns the blob service URL of the account
+func (cfg Config) serviceURL() string {
+	if cfg.ServiceURL != "" {
+		return strings.TrimSuffix(cfg.ServiceURL, "/") + "/"
+	}
+
+	suffix := cfg.EndpointSuffix
+
+	if suffix == "" {
+		suffix = "core.windows.net"
+	}
+
+	return fmt.Sprintf("https://%s.blob.%s/", cfg.AccountName, strings.Trim(suffix, "."))
 }
 
 // Provider implements storage.Provider for Azure Blob Storage
@@ -47,7 +83,7 @@ func newClient(cfg Config) (*azblob.Client, error) {
 		return azblob.NewClientFromConnectionString(cfg.ConnectionString, nil)
 	}
 
-	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.AccountName)
+	serviceURL := cfg.serviceURL()
 
 	if cfg.AccountKey != "" {
 		cred, err := azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
@@ -62,6 +98,16 @@ func newClient(cfg Config) (*azblob.Client, error) {
 		return azblob.NewClientWithNoCredential(urlWithSAS, nil)
 	}
 
+	if cfg.ManagedIdentityClientID != "" {
+		cred, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
+			ID: azidentity.ClientID(cfg.ManagedIdentityClientID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create managed identity credential: %w", err)
+		}
+		return azblob.NewClient(serviceURL, cred, nil)
+	}
+
 	cred, err := azidentity.NewDefaultAzureCredential(nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create default Azure credential: %w", err)
@@ -85,6 +131,15 @@ func ParseConfig(configMap map[string]any) (Config, error) {
 	if v, ok := configMap["connectionString"].(string); ok {
 		cfg.ConnectionString = v
 	}
+	if v, ok := configMap["managedIdentityClientId"].(string); ok {
+		cfg.ManagedIdentityClientID = v
+	}
+	if v, ok := configMap["serviceUrl"].(string); ok {
+		cfg.ServiceURL = v
+	}
+	if v, ok := configMap["endpointSuffix"].(string); ok {
+		cfg.EndpointSuffix = v
+	}
 
 	if cfg.AccountName == "" && cfg.ConnectionString == "" {
 		return cfg, fmt.Errorf("accountName or connectionString is required")
@@ -93,6 +148,20 @@ func ParseConfig(configMap map[string]any) (Config, error) {
 	return cfg, nil
 }
 
+// ConfigFields describes the fields expected by ParseConfig.
+// Either accountName or connectionString must be set.
+func ConfigFields() []storage.ConfigField {
+	return []storage.ConfigField{
+		{Name: "accountName", Label: "Account Name", Type: "string"},
+		{Name: "accountKey", Label: "Account Key", Type: "password"},
+		{Name: "sasToken", Label: "SAS Token", Type: "password"},
+		{Name: "connectionString", Label: "Connection String", Type: "password"},
+		{Name: "managedIdentityClientId", Label: "Managed Identity Client ID", Type: "string"},
+		{Name: "serviceUrl", Label: "Service URL", Type: "string"},
+		{Name: "endpointSuffix", Label: "Endpoint Suffix", Type: "string"},
+	}
+}
+
 // ListContainers returns all Azure containers
 func (p *Provider) ListContainers(ctx context.Context) ([]storage.Container, error) {
 	var containers []storage.Container
@@ -128,6 +197,25 @@ func (p *Provider) CreateContainer(ctx context.Context, name string) error {
 	return nil
 }
 
+// ContainerExists checks whether a container exists and is accessible by reading its properties
+func (p *Provider) ContainerExists(ctx context.Context, name string) (bool, error) {
+	_, err := p.client.ServiceClient().NewContainerClient(name).GetProperties(ctx, nil)
+	if err == nil {
+		return true, nil
+	}
+
+	if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+		return false, nil
+	}
+
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden {
+		return false, fmt.Errorf("container %s: %w", name, storage.ErrAccessDenied)
+	}
+
+	return false, fmt.Errorf("failed to check container: %w", err)
+}
+
 // ListObjects lists blobs in a container. One page per call; use the returned
 // continuation token to fetch the next page. An empty delimiter lists all
 // nested blobs flat (used for folder deletion).
@@ -155,6 +243,7 @@ func (p *Provider) ListObjects(ctx context.Context, container string, opts stora
 			Prefix:     &opts.Prefix,
 			MaxResults: maxResults,
 			Marker:     marker,
+			Include:    azcontainer.ListBlobsInclude{Deleted: opts.IncludeDeleted},
 		})
 
 		page, err := pager.NextPage(ctx)
@@ -163,7 +252,7 @@ func (p *Provider) ListObjects(ctx context.Context, container string, opts stora
 		}
 
 		for _, item := range page.Segment.BlobItems {
-			if obj, ok := blobToObject(item, opts.Prefix); ok {
+			if obj, ok := blobToObject(item, opts); ok {
 				objects = append(objects, obj)
 			}
 		}
@@ -174,6 +263,7 @@ func (p *Provider) ListObjects(ctx context.Context, container string, opts stora
 			Prefix:     &opts.Prefix,
 			MaxResults: maxResults,
 			Marker:     marker,
+			Include:    azcontainer.ListBlobsInclude{Deleted: opts.IncludeDeleted},
 		})
 
 		page, err := pager.NextPage(ctx)
@@ -182,7 +272,7 @@ func (p *Provider) ListObjects(ctx context.Context, container string, opts stora
 		}
 
 		for _, item := range page.Segment.BlobItems {
-			if obj, ok := blobToObject(item, opts.Prefix); ok {
+			if obj, ok := blobToObject(item, opts); ok {
 				objects = append(objects, obj)
 			}
 		}
@@ -196,21 +286,16 @@ func (p *Provider) ListObjects(ctx context.Context, container string, opts stora
 		nextMarker = page.NextMarker
 	}
 
-	result := &storage.ListObjectsResult{
-		Objects:  objects,
-		Prefixes: prefixes,
-	}
+	// The marker is returned empty rather than omitted on the last page
+	return storage.NewListObjectsResult(objects, prefixes, nextMarker), nil
+}
 
-	if nextMarker != nil && *nextMarker != "" {
-		result.IsTruncated = true
-		result.ContinuationToken = nextMarker
+func blobToObject(item *azcontainer.BlobItem, opts storage.ListObjectsOptions) (storage.Object, bool) {
+	if item.Name == nil || *item.Name == opts.Prefix {
+		return storage.Object{}, false
 	}
 
-	return result, nil
-}
-
-func blobToObject(item *azcontainer.BlobItem, prefix string) (storage.Object, bool) {
-	if item.Name == nil || *item.Name == prefix {
+	if item.Properties != nil && item.Properties.LastModified != nil && !opts.MatchesModified(*item.Properties.LastModified) {
 		return storage.Object{}, false
 	}
 
@@ -218,6 +303,7 @@ func blobToObject(item *azcontainer.BlobItem, prefix string) (storage.Object, bo
 		Key:      *item.Name,
 		Name:     storage.GetObjectName(*item.Name),
 		IsFolder: false,
+		Deleted:  item.Deleted != nil && *item.Deleted,
 	}
 
 	if item.Properties != nil {
@@ -265,6 +351,12 @@ func (p *Provider) GetObjectDetails(ctx context.Context, containerName, blobName
 	if props.ContentType != nil {
 		resp.ContentType = props.ContentType
 	}
+	if props.CacheControl != nil {
+		resp.CacheControl = props.CacheControl
+	}
+	if props.ContentDisposition != nil {
+		resp.ContentDisposition = props.ContentDisposition
+	}
 	if props.AccessTier != nil {
 		tier := string(*props.AccessTier)
 		resp.AccessTier = &tier
@@ -273,6 +365,28 @@ func (p *Provider) GetObjectDetails(ctx context.Context, containerName, blobName
 		blobType := string(*props.BlobType)
 		resp.BlobType = &blobType
 	}
+	if props.IsServerEncrypted != nil {
+		resp.Encrypted = props.IsServerEncrypted
+	}
+	if props.ExpiresOn != nil {
+		expiry := props.ExpiresOn.Format(time.RFC3339)
+		resp.Expiry = &expiry
+	}
+	if props.ImmutabilityPolicyExpiresOn != nil {
+		resp.ImmutabilityPolicy = &storage.ImmutabilityPolicy{
+			RetainUntil: props.ImmutabilityPolicyExpiresOn.Format(time.RFC3339),
+		}
+		if props.ImmutabilityPolicyMode != nil {
+			resp.ImmutabilityPolicy.Mode = string(*props.ImmutabilityPolicyMode)
+		}
+	}
+	if props.LegalHold != nil {
+		resp.LegalHold = props.LegalHold
+	}
+	if len(props.ContentMD5) > 0 {
+		sum := hex.EncodeToString(props.ContentMD5)
+		resp.ContentMD5 = &sum
+	}
 	if len(props.Metadata) > 0 {
 		resp.Metadata = make(map[string]string)
 		for k, v := range props.Metadata {
@@ -285,8 +399,47 @@ func (p *Provider) GetObjectDetails(ctx context.Context, containerName, blobName
 	return resp, nil
 }
 
+// GetObject opens a blob for streaming download
+func (p *Provider) GetObject(ctx context.Context, containerName, blobName string, opts storage.GetObjectOptions) (*storage.ObjectContent, error) {
+	blobClient := p.client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
+
+	var downloadOpts *blob.DownloadStreamOptions
+
+	if r := opts.Range; r != nil {
+		downloadOpts = &blob.DownloadStreamOptions{
+			Range: blob.HTTPRange{
+				Offset: r.Offset,
+				Count:  r.Length,
+			},
+		}
+	}
+
+	resp, err := blobClient.DownloadStream(ctx, downloadOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+
+	content := &storage.ObjectContent{
+		Body: resp.NewRetryReader(ctx, nil),
+	}
+	if resp.ContentLength != nil {
+		content.Size = *resp.ContentLength
+	}
+	if resp.ContentType != nil {
+		content.ContentType = *resp.ContentType
+	}
+	if resp.ContentEncoding != nil {
+		content.ContentEncoding = *resp.ContentEncoding
+	}
+	if resp.LastModified != nil {
+		content.LastModified = *resp.LastModified
+	}
+
+	return content, nil
+}
+
 // GetPresignedURL generates a read-only SAS URL for downloading a blob
-func (p *Provider) GetPresignedURL(ctx context.Context, containerName, blobName string, expiresIn int) (string, error) {
+func (p *Provider) GetPresignedURL(ctx context.Context, containerName, blobName string, opts storage.PresignOptions) (string, error) {
 	if p.config.AccountKey == "" {
 		return "", fmt.Errorf("account key required for generating presigned URLs")
 	}
@@ -296,12 +449,14 @@ func (p *Provider) GetPresignedURL(ctx context.Context, containerName, blobName
 		return "", fmt.Errorf("failed to create credential: %w", err)
 	}
 
-	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", p.config.AccountName)
+	serviceURL := p.config.serviceURL()
 	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create client: %w", err)
 	}
 
+	expiresIn := opts.ExpiresIn
+
 	if expiresIn <= 0 {
 		expiresIn = 3600 // Default 1 hour
 	}
@@ -309,33 +464,266 @@ func (p *Provider) GetPresignedURL(ctx context.Context, containerName, blobName
 	blobClient := client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
 	expiry := time.Now().Add(time.Duration(expiresIn) * time.Second)
 
-	sasURL, err := blobClient.GetSASURL(sas.BlobPermissions{Read: true}, expiry, nil)
+	// Signed manually as GetSASURL does not support response header overrides
+	params, err := sas.BlobSignatureValues{
+		ContainerName:      containerName,
+		BlobName:           blobName,
+		Version:            sas.Version,
+		Permissions:        (&sas.BlobPermissions{Read: true}).String(),
+		ExpiryTime:         expiry.UTC(),
+		ContentType:        opts.ResponseContentType,
+		ContentDisposition: opts.ResponseContentDisposition,
+	}.SignWithSharedKey(cred)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate SAS URL: %w", err)
 	}
 
-	return sasURL, nil
+	return blobClient.URL() + "?" + params.Encode(), nil
 }
 
 // UploadObject uploads data to an Azure blob
-func (p *Provider) UploadObject(ctx context.Context, containerName, blobName string, data []byte, contentType string) error {
+func (p *Provider) UploadObject(ctx context.Context, containerName, blobName string, body io.Reader, size int64, opts storage.UploadOptions) error {
+	// Blobs have no ACLs of their own, public access is a container setting
+	if opts.ACL != "" && opts.ACL != "private" {
+		return fmt.Errorf("acl %q: %w", opts.ACL, errors.ErrUnsupported)
+	}
+
 	blobClient := p.client.ServiceClient().NewContainerClient(containerName).NewBlockBlobClient(blobName)
 
-	uploadOpts := &azblob.UploadBufferOptions{}
-	if contentType != "" {
+	uploadOpts := &azblob.UploadStreamOptions{}
+	if opts.ContentType != "" {
 		uploadOpts.HTTPHeaders = &blob.HTTPHeaders{
-			BlobContentType: &contentType,
+			BlobContentType: &opts.ContentType,
+		}
+	}
+	if len(opts.Metadata) > 0 {
+		uploadOpts.Metadata = make(map[string]*string, len(opts.Metadata))
+		for k, v := range opts.Metadata {
+			uploadOpts.Metadata[k] = &v
+		}
+	}
+
+	if opts.IfMatch != "" {
+		etag := azcore.ETag(opts.IfMatch)
+		uploadOpts.AccessConditions = &blob.AccessConditions{
+			ModifiedAccessConditions: &blob.ModifiedAccessConditions{
+				IfMatch: &etag,
+			},
 		}
 	}
 
-	_, err := blobClient.UploadBuffer(ctx, data, uploadOpts)
+	_, err := blobClient.UploadStream(ctx, body, uploadOpts)
 	if err != nil {
+		if bloberror.HasCode(err, bloberror.ConditionNotMet) {
+			return fmt.Errorf("blob %s: %w", blobName, storage.ErrPreconditionFailed)
+		}
+
 		return fmt.Errorf("failed to upload blob: %w", err)
 	}
 
 	return nil
 }
 
+// CreateMultipartUpload starts a block blob upload. Azure keeps no upload
+// state, the parts are staged as uncommitted blocks of the blob.
+func (p *Provider) CreateMultipartUpload(ctx context.Context, containerName, blobName string, opts storage.UploadOptions) (*storage.MultipartUpload, error) {
+	// Blobs have no ACLs of their own, public access is a container setting
+	if opts.ACL != "" && opts.ACL != "private" {
+		return nil, fmt.Errorf("acl %q: %w", opts.ACL, errors.ErrUnsupported)
+	}
+
+	return &storage.MultipartUpload{
+		Container: containerName,
+		Key:       blobName,
+
+		ID: uuid.NewString(),
+
+		Options: opts,
+	}, nil
+}
+
+// UploadPart stages a block of the blob and returns its block ID
+func (p *Provider) UploadPart(ctx context.Context, upload *storage.MultipartUpload, partNumber int, body io.Reader, size int64) (string, error) {
+	blobClient := p.client.ServiceClient().NewContainerClient(upload.Container).NewBlockBlobClient(upload.Key)
+
+	// Block IDs of a blob must all have the same length
+	blockID := base64.StdEncoding.EncodeToString(fmt.Appendf(nil, "%s-%05d", upload.ID, partNumber))
+
+	// Staging a block requires a seekable body, other bodies are buffered in
+	// memory, so callers must bound the part size
+	seeker, ok := body.(io.ReadSeeker)
+
+	if !ok {
+		data, err := io.ReadAll(body)
+
+		if err != nil {
+			return "", fmt.Errorf("failed to read part %d: %w", partNumber, err)
+		}
+
+		seeker = bytes.NewReader(data)
+	}
+
+	_, err := blobClient.StageBlock(ctx, blockID, streaming.NopCloser(seeker), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to stage block %d: %w", partNumber, err)
+	}
+
+	return blockID, nil
+}
+
+// CompleteMultipartUpload commits the staged blocks as the content of the blob
+func (p *Provider) CompleteMultipartUpload(ctx context.Context, upload *storage.MultipartUpload, parts []storage.CompletedPart) error {
+	blobClient := p.client.ServiceClient().NewContainerClient(upload.Container).NewBlockBlobClient(upload.Key)
+
+	parts = slices.Clone(parts)
+
+	slices.SortFunc(parts, func(a, b storage.CompletedPart) int {
+		return cmp.Compare(a.PartNumber, b.PartNumber)
+	})
+
+	blockIDs := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		blockIDs = append(blockIDs, part.ETag)
+	}
+
+	opts := upload.Options
+	commitOpts := &blockblob.CommitBlockListOptions{}
+
+	if opts.ContentType != "" {
+		commitOpts.HTTPHeaders = &blob.HTTPHeaders{
+			BlobContentType: &opts.ContentType,
+		}
+	}
+	if len(opts.Metadata) > 0 {
+		commitOpts.Metadata = make(map[string]*string, len(opts.Metadata))
+		for k, v := range opts.Metadata {
+			commitOpts.Metadata[k] = &v
+		}
+	}
+
+	if opts.IfMatch != "" {
+		etag := azcore.ETag(opts.IfMatch)
+		commitOpts.AccessConditions = &blob.AccessConditions{
+			ModifiedAccessConditions: &blob.ModifiedAccessConditions{
+				IfMatch: &etag,
+			},
+		}
+	}
+
+	_, err := blobClient.CommitBlockList(ctx, blockIDs, commitOpts)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.ConditionNotMet) {
+			return fmt.Errorf("blob %s: %w", upload.Key, storage.ErrPreconditionFailed)
+		}
+
+		return fmt.Errorf("failed to commit blocks: %w", err)
+	}
+
+	return nil
+}
+
+// AbortMultipartUpload has nothing to delete: uncommitted blocks cannot be
+// removed explicitly, Azure discards them after a week
+func (p *Provider) AbortMultipartUpload(ctx context.Context, upload *storage.MultipartUpload) error {
+	return nil
+}
+
+// CopyObject copies a blob within a container and waits for the copy to complete
+func (p *Provider) CopyObject(ctx context.Context, containerName, sourceBlobName, blobName string, metadata map[string]string) error {
+	containerClient := p.client.ServiceClient().NewContainerClient(containerName)
+	blobClient := containerClient.NewBlobClient(blobName)
+
+	copyOpts := &blob.StartCopyFromURLOptions{}
+	if metadata != nil {
+		copyOpts.Metadata = make(map[string]*string, len(metadata))
+		for k, v := range metadata {
+			copyOpts.Metadata[k] = &v
+		}
+	}
+
+	resp, err := blobClient.StartCopyFromURL(ctx, containerClient.NewBlobClient(sourceBlobName).URL(), copyOpts)
+	if err != nil {
+		return fmt.Errorf("failed to copy blob: %w", err)
+	}
+
+	status := resp.CopyStatus
+
+	for status != nil && *status == blob.CopyStatusTypePending {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+
+		props, err := blobClient.GetProperties(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get copy status: %w", err)
+		}
+
+		status = props.CopyStatus
+	}
+
+	if status != nil && *status != blob.CopyStatusTypeSuccess {
+		return fmt.Errorf("failed to copy blob: copy %s", *status)
+	}
+
+	// Without any metadata in the request the source metadata is copied, so clear it explicitly
+	if metadata != nil && len(metadata) == 0 {
+		if _, err := blobClient.SetMetadata(ctx, nil, nil); err != nil {
+			return fmt.Errorf("failed to clear blob metadata: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateObject changes the metadata and HTTP headers of a blob
+func (p *Provider) UpdateObject(ctx context.Context, containerName, blobName string, update storage.ObjectUpdate) error {
+	blobClient := p.client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
+
+	if update.CacheControl != nil || update.ContentDisposition != nil {
+		props, err := blobClient.GetProperties(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get blob properties: %w", err)
+		}
+
+		// SetHTTPHeaders replaces all headers, so carry over the current ones
+		headers := blob.HTTPHeaders{
+			BlobContentType:        props.ContentType,
+			BlobContentEncoding:    props.ContentEncoding,
+			BlobContentLanguage:    props.ContentLanguage,
+			BlobContentMD5:         props.ContentMD5,
+			BlobContentDisposition: props.ContentDisposition,
+			BlobCacheControl:       props.CacheControl,
+		}
+
+		if update.CacheControl != nil {
+			headers.BlobCacheControl = update.CacheControl
+		}
+		if update.ContentDisposition != nil {
+			headers.BlobContentDisposition = update.ContentDisposition
+		}
+
+		if _, err := blobClient.SetHTTPHeaders(ctx, headers, nil); err != nil {
+			return fmt.Errorf("failed to set blob headers: %w", err)
+		}
+	}
+
+	if update.Metadata != nil {
+		metadata := make(map[string]*string, len(update.Metadata))
+		for k, v := range update.Metadata {
+			metadata[k] = &v
+		}
+
+		if _, err := blobClient.SetMetadata(ctx, metadata, nil); err != nil {
+			return fmt.Errorf("failed to set blob metadata: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // DeleteObject deletes a single blob from Azure
 func (p *Provider) DeleteObject(ctx context.Context, containerName, blobName string) error {
 	blobClient := p.client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
@@ -356,4 +744,41 @@ func (p *Provider) DeleteObjects(ctx context.Context, containerName string, keys
 	return nil
 }
 
+// SetAccessTier changes the access tier of a blob
+func (p *Provider) SetAccessTier(ctx context.Context, containerName, blobName, tier string) error {
+	var accessTier blob.AccessTier
+
+	switch strings.ToLower(tier) {
+	case "hot":
+		accessTier = blob.AccessTierHot
+	case "cool":
+		accessTier = blob.AccessTierCool
+	case "cold":
+		accessTier = blob.AccessTierCold
+	case "archive":
+		accessTier = blob.AccessTierArchive
+	default:
+		return fmt.Errorf("%w: %s", storage.ErrUnsupportedAccessTier, tier)
+	}
+
+	blobClient := p.client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
+
+	_, err := blobClient.SetTier(ctx, accessTier, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set access tier: %w", err)
+	}
+	return nil
+}
+
+// UndeleteObject restores a soft-deleted blob and its soft-deleted snapshots
+func (p *Provider) UndeleteObject(ctx context.Context, containerName, blobName string) error {
+	blobClient := p.client.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
+
+	_, err := blobClient.Undelete(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to undelete blob: %w", err)
+	}
+	return nil
+}
+
 var _ storage.Provider = (*Provider)(nil)
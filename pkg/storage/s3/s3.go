@@ -1,17 +1,28 @@
 package s3
 
 import (
-	"bytes"
+	"cmp"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/adrianliechti/granite/pkg/storage"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -19,22 +30,60 @@ import (
 
 // Config contains S3 connection configuration
 type Config struct {
-	Endpoint        string `json:"endpoint,omitempty"`
-	Region          string `json:"region"`
-	AccessKeyID     string `json:"accessKeyId"`
-	SecretAccessKey string `json:"secretAccessKey"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Region   string `json:"region"`
+
+	// Optional: static key pair, the default AWS credential chain (environment,
+	// shared config, IAM role) is used if both are empty
+	AccessKeyID     string `json:"accessKeyId,omitempty"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+
+	// Optional: key pair used only to sign presigned URLs, when they must be
+	// signed by other credentials than the ones used for API calls
+	SigningAccessKeyID     string `json:"signingAccessKeyId,omitempty"`
+	SigningSecretAccessKey string `json:"signingSecretAccessKey,omitempty"`
 }
 
 // Provider implements storage.Provider for AWS S3
 type Provider struct {
 	client *s3.Client
 	config Config
+	region string
 }
 
+// bucketRegions caches the detected region per bucket (bucket names are globally unique on AWS)
+var bucketRegions sync.Map
+
 // New creates a new S3 storage provider
 func New(ctx context.Context, cfg Config) (*Provider, error) {
-	// Default region for S3-compatible services
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		return nil, errors.New("accessKeyId and secretAccessKey must be set together")
+	}
+
+	if (cfg.SigningAccessKeyID == "") != (cfg.SigningSecretAccessKey == "") {
+		return nil, errors.New("signingAccessKeyId and signingSecretAccessKey must be set together")
+	}
+
+	var credentialsProvider aws.CredentialsProvider
 	region := cfg.Region
+
+	if cfg.AccessKeyID != "" {
+		credentialsProvider = credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")
+	} else {
+		defaults, err := awsconfig.LoadDefaultConfig(ctx)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load default AWS credentials: %w", err)
+		}
+
+		credentialsProvider = defaults.Credentials
+
+		if region == "" {
+			region = defaults.Region
+		}
+	}
+
+	// Default region for S3-compatible services
 	if region == "" {
 		region = "us-east-1"
 	}
@@ -53,13 +102,9 @@ func New(ctx context.Context, cfg Config) (*Provider, error) {
 
 	// Create S3 client with options
 	options := s3.Options{
-		Region: region,
-		Credentials: credentials.NewStaticCredentialsProvider(
-			cfg.AccessKeyID,
-			cfg.SecretAccessKey,
-			"",
-		),
-		HTTPClient: httpClient,
+		Region:      region,
+		Credentials: credentialsProvider,
+		HTTPClient:  httpClient,
 	}
 
 	// Custom endpoint (MinIO, RustFS, ...) - AWS itself must resolve its regional endpoint
@@ -73,6 +118,7 @@ func New(ctx context.Context, cfg Config) (*Provider, error) {
 	return &Provider{
 		client: client,
 		config: cfg,
+		region: region,
 	}, nil
 }
 
@@ -87,20 +133,41 @@ func ParseConfig(configMap map[string]any) (Config, error) {
 		cfg.Region = v
 	}
 	// Region is optional - defaults to us-east-1 for S3-compatible services
+	// Keys are optional - the default AWS credential chain is used without them
 	if v, ok := configMap["accessKeyId"].(string); ok {
 		cfg.AccessKeyID = v
-	} else {
-		return cfg, fmt.Errorf("accessKeyId is required")
 	}
 	if v, ok := configMap["secretAccessKey"].(string); ok {
 		cfg.SecretAccessKey = v
-	} else {
-		return cfg, fmt.Errorf("secretAccessKey is required")
+	}
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		return cfg, fmt.Errorf("accessKeyId and secretAccessKey must be set together")
+	}
+	if v, ok := configMap["signingAccessKeyId"].(string); ok {
+		cfg.SigningAccessKeyID = v
+	}
+	if v, ok := configMap["signingSecretAccessKey"].(string); ok {
+		cfg.SigningSecretAccessKey = v
+	}
+	if (cfg.SigningAccessKeyID == "") != (cfg.SigningSecretAccessKey == "") {
+		return cfg, fmt.Errorf("signingAccessKeyId and signingSecretAccessKey must be set together")
 	}
 
 	return cfg, nil
 }
 
+// ConfigFields describes the fields expected by ParseConfig
+func ConfigFields() []storage.ConfigField {
+	return []storage.ConfigField{
+		{Name: "endpoint", Label: "Endpoint", Type: "string"},
+		{Name: "region", Label: "Region", Type: "string"},
+		{Name: "accessKeyId", Label: "Access Key ID", Type: "string"},
+		{Name: "secretAccessKey", Label: "Secret Access Key", Type: "password"},
+		{Name: "signingAccessKeyId", Label: "Signing Access Key ID", Type: "string"},
+		{Name: "signingSecretAccessKey", Label: "Signing Secret Access Key", Type: "password"},
+	}
+}
+
 // ListContainers returns all S3 buckets
 func (p *Provider) ListContainers(ctx context.Context) ([]storage.Container, error) {
 	result, err := p.client.ListBuckets(ctx, &s3.ListBucketsInput{})
@@ -117,6 +184,13 @@ func (p *Provider) ListContainers(ctx context.Context) ([]storage.Container, err
 			t := b.CreationDate.Format(time.RFC3339)
 			container.CreatedAt = &t
 		}
+		if b.BucketRegion != nil && *b.BucketRegion != "" {
+			bucketRegions.Store(*b.Name, *b.BucketRegion)
+			container.Region = b.BucketRegion
+		} else if p.config.Endpoint == "" {
+			region := p.bucketRegion(ctx, *b.Name)
+			container.Region = &region
+		}
 		containers[i] = container
 	}
 
@@ -125,15 +199,47 @@ func (p *Provider) ListContainers(ctx context.Context) ([]storage.Container, err
 
 // CreateContainer creates a new S3 bucket
 func (p *Provider) CreateContainer(ctx context.Context, name string) error {
-	_, err := p.client.CreateBucket(ctx, &s3.CreateBucketInput{
+	input := &s3.CreateBucketInput{
 		Bucket: aws.String(name),
-	})
+	}
+
+	// AWS rejects a location constraint for us-east-1 but requires it everywhere else
+	if p.config.Endpoint == "" && p.region != "us-east-1" {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(p.region),
+		}
+	}
+
+	_, err := p.client.CreateBucket(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to create bucket: %w", err)
 	}
 	return nil
 }
 
+// ContainerExists checks whether a bucket exists and is accessible using HeadBucket
+func (p *Provider) ContainerExists(ctx context.Context, name string) (bool, error) {
+	_, err := p.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(name),
+	}, p.withBucketRegion(ctx, name))
+	if err == nil {
+		return true, nil
+	}
+
+	// HeadBucket responses have no body, so only the status code tells the cause
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusNotFound:
+			return false, nil
+		case http.StatusForbidden:
+			return false, fmt.Errorf("bucket %s: %w", name, storage.ErrAccessDenied)
+		}
+	}
+
+	return false, fmt.Errorf("failed to check bucket: %w", err)
+}
+
 // ListObjects lists objects in a container
 func (p *Provider) ListObjects(ctx context.Context, container string, opts storage.ListObjectsOptions) (*storage.ListObjectsResult, error) {
 	input := &s3.ListObjectsV2Input{
@@ -149,7 +255,7 @@ func (p *Provider) ListObjects(ctx context.Context, container string, opts stora
 		input.ContinuationToken = aws.String(opts.ContinuationToken)
 	}
 
-	result, err := p.client.ListObjectsV2(ctx, input)
+	result, err := p.client.ListObjectsV2(ctx, input, p.withBucketRegion(ctx, container))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects: %w", err)
 	}
@@ -161,6 +267,10 @@ func (p *Provider) ListObjects(ctx context.Context, container string, opts stora
 			continue
 		}
 
+		if obj.LastModified != nil && !opts.MatchesModified(*obj.LastModified) {
+			continue
+		}
+
 		o := storage.Object{
 			Key:      *obj.Key,
 			Name:     storage.GetObjectName(*obj.Key),
@@ -181,24 +291,17 @@ func (p *Provider) ListObjects(ctx context.Context, container string, opts stora
 		prefixes[i] = *prefix.Prefix
 	}
 
-	resp := &storage.ListObjectsResult{
-		Objects:     objects,
-		Prefixes:    prefixes,
-		IsTruncated: result.IsTruncated != nil && *result.IsTruncated,
-	}
-	if result.NextContinuationToken != nil {
-		resp.ContinuationToken = result.NextContinuationToken
-	}
-
-	return resp, nil
+	// A truncated listing always has a token for the next page
+	return storage.NewListObjectsResult(objects, prefixes, result.NextContinuationToken), nil
 }
 
 // GetObjectDetails returns detailed metadata for an object
 func (p *Provider) GetObjectDetails(ctx context.Context, container, key string) (*storage.ObjectDetails, error) {
 	result, err := p.client.HeadObject(ctx, &s3.HeadObjectInput{
-		Bucket: aws.String(container),
-		Key:    aws.String(key),
-	})
+		Bucket:       aws.String(container),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	}, p.withBucketRegion(ctx, container))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get object details: %w", err)
 	}
@@ -216,6 +319,12 @@ func (p *Provider) GetObjectDetails(ctx context.Context, container, key string)
 	if result.ContentType != nil {
 		resp.ContentType = result.ContentType
 	}
+	if result.CacheControl != nil {
+		resp.CacheControl = result.CacheControl
+	}
+	if result.ContentDisposition != nil {
+		resp.ContentDisposition = result.ContentDisposition
+	}
 	if result.VersionId != nil {
 		resp.VersionID = result.VersionId
 	}
@@ -226,22 +335,111 @@ func (p *Provider) GetObjectDetails(ctx context.Context, container, key string)
 	if len(result.Metadata) > 0 {
 		resp.Metadata = result.Metadata
 	}
+	if result.ServerSideEncryption != "" {
+		sse := string(result.ServerSideEncryption)
+		resp.ServerSideEncryption = &sse
+		resp.Encrypted = aws.Bool(true)
+	}
+	if result.SSEKMSKeyId != nil {
+		resp.SSEKMSKeyID = result.SSEKMSKeyId
+	}
+	if result.Expiration != nil {
+		if expiry, rule, ok := parseExpiration(*result.Expiration); ok {
+			resp.Expiry = aws.String(expiry.Format(time.RFC3339))
+			if rule != "" {
+				resp.ExpiryRule = aws.String(rule)
+			}
+		}
+	}
+	if result.ObjectLockMode != "" && result.ObjectLockRetainUntilDate != nil {
+		resp.ImmutabilityPolicy = &storage.ImmutabilityPolicy{
+			Mode:        string(result.ObjectLockMode),
+			RetainUntil: result.ObjectLockRetainUntilDate.Format(time.RFC3339),
+		}
+	}
+	if result.ObjectLockLegalHoldStatus != "" {
+		resp.LegalHold = aws.Bool(result.ObjectLockLegalHoldStatus == types.ObjectLockLegalHoldStatusOn)
+	}
+
+	// The ETag is the MD5 of the content unless the object was uploaded in
+	// parts ("<hash>-<parts>") or encrypted with KMS or customer keys
+	if etag := strings.Trim(aws.ToString(result.ETag), `"`); len(etag) == 32 && !strings.Contains(etag, "-") &&
+		!strings.HasPrefix(string(result.ServerSideEncryption), "aws:kms") && result.SSECustomerAlgorithm == nil {
+		resp.ContentMD5 = aws.String(etag)
+	}
+	if result.ChecksumSHA256 != nil && result.ChecksumType != types.ChecksumTypeComposite {
+		if sum, err := base64.StdEncoding.DecodeString(*result.ChecksumSHA256); err == nil {
+			resp.ChecksumSHA256 = aws.String(hex.EncodeToString(sum))
+		}
+	}
 
 	return resp, nil
 }
 
+// GetObject opens an S3 object for streaming download
+func (p *Provider) GetObject(ctx context.Context, container, key string, opts storage.GetObjectOptions) (*storage.ObjectContent, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(container),
+		Key:    aws.String(key),
+	}
+
+	if r := opts.Range; r != nil {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", r.Offset, r.Offset+r.Length-1))
+	}
+
+	result, err := p.client.GetObject(ctx, input, p.withBucketRegion(ctx, container))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	content := &storage.ObjectContent{
+		Body:            result.Body,
+		Size:            aws.ToInt64(result.ContentLength),
+		ContentType:     aws.ToString(result.ContentType),
+		ContentEncoding: aws.ToString(result.ContentEncoding),
+	}
+	if result.LastModified != nil {
+		content.LastModified = *result.LastModified
+	}
+
+	return content, nil
+}
+
 // GetPresignedURL generates a presigned URL for downloading an object
-func (p *Provider) GetPresignedURL(ctx context.Context, container, key string, expiresIn int) (string, error) {
+func (p *Provider) GetPresignedURL(ctx context.Context, container, key string, opts storage.PresignOptions) (string, error) {
 	presignClient := s3.NewPresignClient(p.client)
 
+	expiresIn := opts.ExpiresIn
+
 	if expiresIn <= 0 {
 		expiresIn = 3600 // Default 1 hour
 	}
 
-	result, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(container),
 		Key:    aws.String(key),
-	}, s3.WithPresignExpires(time.Duration(expiresIn)*time.Second))
+	}
+
+	if opts.ResponseContentType != "" {
+		input.ResponseContentType = aws.String(opts.ResponseContentType)
+	}
+	if opts.ResponseContentDisposition != "" {
+		input.ResponseContentDisposition = aws.String(opts.ResponseContentDisposition)
+	}
+
+	presignOptions := []func(*s3.PresignOptions){
+		s3.WithPresignExpires(time.Duration(expiresIn) * time.Second),
+		s3.WithPresignClientFromClientOptions(p.withBucketRegion(ctx, container)),
+	}
+
+	// Sign with the dedicated key pair if one is configured
+	if p.config.SigningAccessKeyID != "" {
+		presignOptions = append(presignOptions, s3.WithPresignClientFromClientOptions(func(o *s3.Options) {
+			o.Credentials = credentials.NewStaticCredentialsProvider(p.config.SigningAccessKeyID, p.config.SigningSecretAccessKey, "")
+		}))
+	}
+
+	result, err := presignClient.PresignGetObject(ctx, input, presignOptions...)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
@@ -251,31 +449,389 @@ func (p *Provider) GetPresignedURL(ctx context.Context, container, key string, e
 }
 
 // UploadObject uploads data to an S3 object
-func (p *Provider) UploadObject(ctx context.Context, container, key string, data []byte, contentType string) error {
+func (p *Provider) UploadObject(ctx context.Context, container, key string, body io.Reader, size int64, opts storage.UploadOptions) error {
 	input := &s3.PutObjectInput{
 		Bucket: aws.String(container),
 		Key:    aws.String(key),
-		Body:   bytes.NewReader(data),
+		Body:   body,
 	}
 
-	if contentType != "" {
-		input.ContentType = aws.String(contentType)
+	if size >= 0 {
+		input.ContentLength = aws.Int64(size)
 	}
 
-	_, err := p.client.PutObject(ctx, input)
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	if len(opts.Metadata) > 0 {
+		input.Metadata = opts.Metadata
+	}
+	if opts.IfMatch != "" {
+		input.IfMatch = aws.String(opts.IfMatch)
+	}
+	if opts.ACL != "" {
+		input.ACL = types.ObjectCannedACL(opts.ACL)
+	}
+	if opts.ServerSideEncryption != "" {
+		input.ServerSideEncryption = types.ServerSideEncryption(opts.ServerSideEncryption)
+	}
+	if opts.SSEKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(opts.SSEKMSKeyID)
+
+		// A KMS key implies KMS encryption
+		if input.ServerSideEncryption == "" {
+			input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		}
+	}
+
+	_, err := p.client.PutObject(ctx, input, p.bodyOptions(ctx, container, body)...)
 	if err != nil {
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusPreconditionFailed {
+			return fmt.Errorf("object %s: %w", key, storage.ErrPreconditionFailed)
+		}
+
 		return fmt.Errorf("failed to upload object: %w", err)
 	}
 
 	return nil
 }
 
+// bodyOptions returns the request options for sending body to a bucket
+func (p *Provider) bodyOptions(ctx context.Context, container string, body io.Reader) []func(*s3.Options) {
+	optFns := []func(*s3.Options){
+		p.withBucketRegion(ctx, container),
+	}
+
+	// Streams that cannot be rewound can't be hashed for signing, send them as unsigned payload
+	if _, ok := body.(io.Seeker); !ok {
+		optFns = append(optFns,
+			s3.WithAPIOptions(v4.SwapComputePayloadSHA256ForUnsignedPayloadMiddleware),
+			func(o *s3.Options) {
+				o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			},
+		)
+	}
+
+	return optFns
+}
+
+// CreateMultipartUpload starts an S3 multipart upload
+func (p *Provider) CreateMultipartUpload(ctx context.Context, container, key string, opts storage.UploadOptions) (*storage.MultipartUpload, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(container),
+		Key:    aws.String(key),
+	}
+
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	if len(opts.Metadata) > 0 {
+		input.Metadata = opts.Metadata
+	}
+	if opts.ACL != "" {
+		input.ACL = types.ObjectCannedACL(opts.ACL)
+	}
+	if opts.ServerSideEncryption != "" {
+		input.ServerSideEncryption = types.ServerSideEncryption(opts.ServerSideEncryption)
+	}
+	if opts.SSEKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(opts.SSEKMSKeyID)
+
+		// A KMS key implies KMS encryption
+		if input.ServerSideEncryption == "" {
+			input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		}
+	}
+
+	resp, err := p.client.CreateMultipartUpload(ctx, input, p.withBucketRegion(ctx, container))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+
+	return &storage.MultipartUpload{
+		Container: container,
+		Key:       key,
+
+		ID: aws.ToString(resp.UploadId),
+
+		Options: opts,
+	}, nil
+}
+
+// UploadPart uploads a part of an S3 multipart upload. All parts but the last
+// must be at least 5 MiB, which S3 only checks when the upload is completed.
+func (p *Provider) UploadPart(ctx context.Context, upload *storage.MultipartUpload, partNumber int, body io.Reader, size int64) (string, error) {
+	input := &s3.UploadPartInput{
+		Bucket:     aws.String(upload.Container),
+		Key:        aws.String(upload.Key),
+		UploadId:   aws.String(upload.ID),
+		PartNumber: aws.Int32(int32(partNumber)),
+		Body:       body,
+	}
+
+	if size >= 0 {
+		input.ContentLength = aws.Int64(size)
+	}
+
+	resp, err := p.client.UploadPart(ctx, input, p.bodyOptions(ctx, upload.Container, body)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+
+	return aws.ToString(resp.ETag), nil
+}
+
+// CompleteMultipartUpload assembles the object from its uploaded parts
+func (p *Provider) CompleteMultipartUpload(ctx context.Context, upload *storage.MultipartUpload, parts []storage.CompletedPart) error {
+	completed := make([]types.CompletedPart, 0, len(parts))
+
+	for _, part := range parts {
+		completed = append(completed, types.CompletedPart{
+			PartNumber: aws.Int32(int32(part.PartNumber)),
+			ETag:       aws.String(part.ETag),
+		})
+	}
+
+	slices.SortFunc(completed, func(a, b types.CompletedPart) int {
+		return cmp.Compare(*a.PartNumber, *b.PartNumber)
+	})
+
+	input := &s3.CompleteMultipartUploadInput{
+		Bucket:   aws.String(upload.Container),
+		Key:      aws.String(upload.Key),
+		UploadId: aws.String(upload.ID),
+
+		MultipartUpload: &types.CompletedMultipartUpload{
+			Parts: completed,
+		},
+	}
+
+	if upload.Options.IfMatch != "" {
+		input.IfMatch = aws.String(upload.Options.IfMatch)
+	}
+
+	_, err := p.client.CompleteMultipartUpload(ctx, input, p.withBucketRegion(ctx, upload.Container))
+	if err != nil {
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusPreconditionFailed {
+			return fmt.Errorf("object %s: %w", upload.Key, storage.ErrPreconditionFailed)
+		}
+
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	return nil
+}
+
+// AbortMultipartUpload aborts an S3 multipart upload and deletes its parts
+func (p *Provider) AbortMultipartUpload(ctx context.Context, upload *storage.MultipartUpload) error {
+	_, err := p.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(upload.Container),
+		Key:      aws.String(upload.Key),
+		UploadId: aws.String(upload.ID),
+	}, p.withBucketRegion(ctx, upload.Container))
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	return nil
+}
+
+// maxCopySize is the largest object a single CopyObject request can copy
+const maxCopySize = 5 << 30
+
+// copyPartSize is the part size of copies of larger objects
+const copyPartSize = 512 << 20
+
+// CopyObject copies an object within a bucket. Objects larger than 5 GiB are
+// copied in parts.
+func (p *Provider) CopyObject(ctx context.Context, container, sourceKey, key string, metadata map[string]string) error {
+	regionOpt := p.withBucketRegion(ctx, container)
+
+	// A copy gets the bucket's default storage class and encryption, and
+	// replacing the metadata also replaces the content headers, so carry them over
+	head, err := p.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(container),
+		Key:    aws.String(sourceKey),
+	}, regionOpt)
+	if err != nil {
+		return fmt.Errorf("failed to get object metadata: %w", err)
+	}
+
+	if aws.ToInt64(head.ContentLength) > maxCopySize {
+		return p.copyObjectParts(ctx, container, sourceKey, key, metadata, head)
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(container),
+		Key:               aws.String(key),
+		CopySource:        aws.String(url.PathEscape(container + "/" + sourceKey)),
+		MetadataDirective: types.MetadataDirectiveCopy,
+
+		StorageClass:         types.StorageClass(head.StorageClass),
+		ServerSideEncryption: head.ServerSideEncryption,
+		SSEKMSKeyId:          head.SSEKMSKeyId,
+		BucketKeyEnabled:     head.BucketKeyEnabled,
+	}
+
+	if metadata != nil {
+		input.MetadataDirective = types.MetadataDirectiveReplace
+		input.Metadata = metadata
+		input.ContentType = head.ContentType
+		input.ContentEncoding = head.ContentEncoding
+		input.ContentDisposition = head.ContentDisposition
+		input.ContentLanguage = head.ContentLanguage
+		input.CacheControl = head.CacheControl
+	}
+
+	_, err = p.client.CopyObject(ctx, input, regionOpt)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	return nil
+}
+
+// copyObjectParts copies an object with a multipart upload, whose parts are
+// copied from byte ranges of the source on the server side
+func (p *Provider) copyObjectParts(ctx context.Context, container, sourceKey, key string, metadata map[string]string, head *s3.HeadObjectOutput) error {
+	regionOpt := p.withBucketRegion(ctx, container)
+
+	if metadata == nil {
+		metadata = head.Metadata
+	}
+
+	upload, err := p.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:   aws.String(container),
+		Key:      aws.String(key),
+		Metadata: metadata,
+
+		ContentType:        head.ContentType,
+		ContentEncoding:    head.ContentEncoding,
+		ContentDisposition: head.ContentDisposition,
+		ContentLanguage:    head.ContentLanguage,
+		CacheControl:       head.CacheControl,
+
+		StorageClass:         types.StorageClass(head.StorageClass),
+		ServerSideEncryption: head.ServerSideEncryption,
+		SSEKMSKeyId:          head.SSEKMSKeyId,
+		BucketKeyEnabled:     head.BucketKeyEnabled,
+	}, regionOpt)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+
+	abort := func() {
+		p.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(container),
+			Key:      aws.String(key),
+			UploadId: upload.UploadId,
+		}, regionOpt)
+	}
+
+	size := aws.ToInt64(head.ContentLength)
+
+	var parts []types.CompletedPart
+
+	for offset, number := int64(0), int32(1); offset < size; offset, number = offset+copyPartSize, number+1 {
+		end := min(offset+copyPartSize, size) - 1
+
+		// The ETag condition fails the copy if the source changes between parts
+		part, err := p.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:            aws.String(container),
+			Key:               aws.String(key),
+			UploadId:          upload.UploadId,
+			PartNumber:        aws.Int32(number),
+			CopySource:        aws.String(url.PathEscape(container + "/" + sourceKey)),
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", offset, end)),
+			CopySourceIfMatch: head.ETag,
+		}, regionOpt)
+		if err != nil {
+			abort()
+			return fmt.Errorf("failed to copy object part %d: %w", number, err)
+		}
+
+		parts = append(parts, types.CompletedPart{
+			ETag:       part.CopyPartResult.ETag,
+			PartNumber: aws.Int32(number),
+		})
+	}
+
+	_, err = p.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(container),
+		Key:             aws.String(key),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	}, regionOpt)
+	if err != nil {
+		abort()
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	return nil
+}
+
+// UpdateObject changes the metadata and headers of an object by copying it onto itself
+func (p *Provider) UpdateObject(ctx context.Context, container, key string, update storage.ObjectUpdate) error {
+	regionOpt := p.withBucketRegion(ctx, container)
+
+	head, err := p.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(container),
+		Key:    aws.String(key),
+	}, regionOpt)
+	if err != nil {
+		return fmt.Errorf("failed to get object metadata: %w", err)
+	}
+
+	// Replacing the metadata resets everything not given in the request,
+	// so carry over the current headers, storage class and encryption
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(container),
+		Key:               aws.String(key),
+		CopySource:        aws.String(url.PathEscape(container + "/" + key)),
+		MetadataDirective: types.MetadataDirectiveReplace,
+
+		Metadata:           head.Metadata,
+		ContentType:        head.ContentType,
+		ContentEncoding:    head.ContentEncoding,
+		ContentDisposition: head.ContentDisposition,
+		ContentLanguage:    head.ContentLanguage,
+		CacheControl:       head.CacheControl,
+
+		StorageClass:         types.StorageClass(head.StorageClass),
+		ServerSideEncryption: head.ServerSideEncryption,
+		SSEKMSKeyId:          head.SSEKMSKeyId,
+	}
+
+	if update.Metadata != nil {
+		input.Metadata = update.Metadata
+	}
+	if update.CacheControl != nil {
+		input.CacheControl = headerValue(*update.CacheControl)
+	}
+	if update.ContentDisposition != nil {
+		input.ContentDisposition = headerValue(*update.ContentDisposition)
+	}
+
+	if _, err := p.client.CopyObject(ctx, input, regionOpt); err != nil {
+		return fmt.Errorf("failed to update object: %w", err)
+	}
+	return nil
+}
+
+// headerValue returns nil for an empty header, which removes it from the object
+func headerValue(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return aws.String(value)
+}
+
 // DeleteObject deletes a single object from S3
 func (p *Provider) DeleteObject(ctx context.Context, container, key string) error {
 	_, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(container),
 		Key:    aws.String(key),
-	})
+	}, p.withBucketRegion(ctx, container))
 	if err != nil {
 		return fmt.Errorf("failed to delete object: %w", err)
 	}
@@ -288,6 +844,8 @@ func (p *Provider) DeleteObjects(ctx context.Context, container string, keys []s
 		return nil
 	}
 
+	regionOpt := p.withBucketRegion(ctx, container)
+
 	// S3 DeleteObjects has a limit of 1000 keys per request
 	const batchSize = 1000
 	for i := 0; i < len(keys); i += batchSize {
@@ -310,7 +868,7 @@ func (p *Provider) DeleteObjects(ctx context.Context, container string, keys []s
 				Objects: objects,
 				Quiet:   aws.Bool(true),
 			},
-		})
+		}, regionOpt)
 		if err != nil {
 			return fmt.Errorf("failed to delete objects: %w", err)
 		}
@@ -325,5 +883,130 @@ func (p *Provider) DeleteObjects(ctx context.Context, container string, keys []s
 	return nil
 }
 
+// UndeleteObject is not supported, S3 has no soft delete (deleted objects of
+// versioned buckets are hidden behind a delete marker instead)
+func (p *Provider) UndeleteObject(ctx context.Context, container, key string) error {
+	return fmt.Errorf("undelete: %w", errors.ErrUnsupported)
+}
+
+// SetAccessTier changes the storage class of an object by copying it onto itself.
+// Azure tier names are mapped to their closest S3 storage class; S3 storage
+// class names are passed through as-is.
+func (p *Provider) SetAccessTier(ctx context.Context, container, key, tier string) error {
+	var storageClass types.StorageClass
+
+	switch strings.ToLower(tier) {
+	case "hot":
+		storageClass = types.StorageClassStandard
+	case "cool":
+		storageClass = types.StorageClassStandardIa
+	case "cold":
+		storageClass = types.StorageClassGlacierIr
+	case "archive":
+		storageClass = types.StorageClassDeepArchive
+	default:
+		storageClass = types.StorageClass(strings.ToUpper(tier))
+
+		if !slices.Contains(storageClass.Values(), storageClass) {
+			return fmt.Errorf("%w: %s", storage.ErrUnsupportedAccessTier, tier)
+		}
+	}
+
+	// The copy is encrypted with the bucket default unless the object's own
+	// encryption (e.g. a specific KMS key) is requested again
+	head, err := p.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(container),
+		Key:    aws.String(key),
+	}, p.withBucketRegion(ctx, container))
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+
+	_, err = p.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(container),
+		Key:               aws.String(key),
+		CopySource:        aws.String(url.PathEscape(container + "/" + key)),
+		StorageClass:      storageClass,
+		MetadataDirective: types.MetadataDirectiveCopy,
+
+		ServerSideEncryption: head.ServerSideEncryption,
+		SSEKMSKeyId:          head.SSEKMSKeyId,
+		BucketKeyEnabled:     head.BucketKeyEnabled,
+	}, p.withBucketRegion(ctx, container))
+	if err != nil {
+		return fmt.Errorf("failed to set storage class: %w", err)
+	}
+	return nil
+}
+
+// expirationField matches the fields of an x-amz-expiration header, e.g.
+// expiry-date="Fri, 23 Dec 2012 00:00:00 GMT", rule-id="picture-deletion-rule"
+var expirationField = regexp.MustCompile(`([a-z-]+)="([^"]*)"`)
+
+// parseExpiration returns the expiry date and lifecycle rule of an x-amz-expiration header
+func parseExpiration(header string) (time.Time, string, bool) {
+	var expiry time.Time
+	var rule string
+
+	for _, m := range expirationField.FindAllStringSubmatch(header, -1) {
+		switch m[1] {
+		case "expiry-date":
+			t, err := time.Parse(http.TimeFormat, m[2])
+
+			if err != nil {
+				return time.Time{}, "", false
+			}
+
+			expiry = t
+
+		case "rule-id":
+			rule = m[2]
+		}
+	}
+
+	return expiry, rule, !expiry.IsZero()
+}
+
+// bucketRegion returns the region a bucket lives in. Custom endpoints are
+// assumed to be single-region, so detection only happens against AWS itself.
+func (p *Provider) bucketRegion(ctx context.Context, bucket string) string {
+	if p.config.Endpoint != "" {
+		return p.region
+	}
+
+	if v, ok := bucketRegions.Load(bucket); ok {
+		return v.(string)
+	}
+
+	result, err := p.client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return p.region
+	}
+
+	region := string(result.LocationConstraint)
+
+	// Legacy location constraints for the original regions
+	switch region {
+	case "":
+		region = "us-east-1"
+	case "EU":
+		region = "eu-west-1"
+	}
+
+	bucketRegions.Store(bucket, region)
+	return region
+}
+
+// withBucketRegion returns a client option that routes a request to the bucket's region
+func (p *Provider) withBucketRegion(ctx context.Context, bucket string) func(*s3.Options) {
+	region := p.bucketRegion(ctx, bucket)
+
+	return func(o *s3.Options) {
+		o.Region = region
+	}
+}
+
 // Ensure Provider implements storage.Provider
 var _ storage.Provider = (*Provider)(nil)
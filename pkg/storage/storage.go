@@ -2,10 +2,23 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"io"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
+// ErrAccessDenied is returned when the credentials lack permission for an operation
+var ErrAccessDenied = errors.New("access denied")
+
+// ErrPreconditionFailed is returned when a conditional write finds the object changed
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ErrUnsupportedAccessTier is returned for access tiers the provider does not know
+var ErrUnsupportedAccessTier = errors.New("unsupported access tier")
+
 // Provider defines the interface for object storage operations
 type Provider interface {
 	// ListContainers returns all containers
@@ -14,23 +27,57 @@ type Provider interface {
 	// CreateContainer creates a new container
 	CreateContainer(ctx context.Context, name string) error
 
+	// ContainerExists reports whether a container exists; it returns ErrAccessDenied
+	// if the container cannot be accessed with the configured credentials
+	ContainerExists(ctx context.Context, name string) (bool, error)
+
 	// ListObjects lists objects in a container with optional prefix filtering
 	ListObjects(ctx context.Context, container string, opts ListObjectsOptions) (*ListObjectsResult, error)
 
 	// GetObjectDetails returns detailed metadata for a specific object
 	GetObjectDetails(ctx context.Context, container, key string) (*ObjectDetails, error)
 
+	// GetObject opens an object (or a byte range of it) for streaming download; the caller must close the body
+	GetObject(ctx context.Context, container, key string, opts GetObjectOptions) (*ObjectContent, error)
+
 	// GetPresignedURL generates a presigned URL for downloading an object
-	GetPresignedURL(ctx context.Context, container, key string, expiresIn int) (string, error)
+	GetPresignedURL(ctx context.Context, container, key string, opts PresignOptions) (string, error)
+
+	// UploadObject streams an object to the storage provider; size is -1 if unknown
+	UploadObject(ctx context.Context, container, key string, body io.Reader, size int64, opts UploadOptions) error
 
-	// UploadObject uploads an object to the storage provider
-	UploadObject(ctx context.Context, container, key string, data []byte, contentType string) error
+	// CreateMultipartUpload starts an upload of an object in separately uploaded parts
+	CreateMultipartUpload(ctx context.Context, container, key string, opts UploadOptions) (*MultipartUpload, error)
+
+	// UploadPart uploads a part of a multipart upload and returns the value to pass
+	// as CompletedPart.ETag; parts are numbered from 1 and may be uploaded in parallel
+	UploadPart(ctx context.Context, upload *MultipartUpload, partNumber int, body io.Reader, size int64) (string, error)
+
+	// CompleteMultipartUpload assembles the object from the given parts, ordered by part number
+	CompleteMultipartUpload(ctx context.Context, upload *MultipartUpload, parts []CompletedPart) error
+
+	// AbortMultipartUpload discards a multipart upload and the parts uploaded so far
+	AbortMultipartUpload(ctx context.Context, upload *MultipartUpload) error
+
+	// CopyObject copies an object of any size within a container, keeping its
+	// content headers, storage class and encryption. If metadata is not nil,
+	// it replaces the user metadata of the copy.
+	CopyObject(ctx context.Context, container, sourceKey, key string, metadata map[string]string) error
+
+	// UpdateObject changes the user metadata and HTTP headers of an existing object
+	UpdateObject(ctx context.Context, container, key string, update ObjectUpdate) error
 
 	// DeleteObject deletes a single object from storage
 	DeleteObject(ctx context.Context, container, key string) error
 
 	// DeleteObjects deletes multiple objects from storage (for prefix/folder deletion)
 	DeleteObjects(ctx context.Context, container string, keys []string) error
+
+	// UndeleteObject restores a soft-deleted object; errors.ErrUnsupported if the provider has no soft delete
+	UndeleteObject(ctx context.Context, container, key string) error
+
+	// SetAccessTier moves an object to another access tier (hot, cool, cold, archive)
+	SetAccessTier(ctx context.Context, container, key, tier string) error
 }
 
 // Container represents a storage container
@@ -49,6 +96,7 @@ type Object struct {
 	ETag         *string `json:"etag,omitempty"`
 	ContentType  *string `json:"contentType,omitempty"`
 	IsFolder     bool    `json:"isFolder"`
+	Deleted      bool    `json:"deleted,omitempty"` // soft-deleted, see ListObjectsOptions.IncludeDeleted
 }
 
 // ListObjectsOptions contains options for listing objects
@@ -57,6 +105,29 @@ type ListObjectsOptions struct {
 	Delimiter         string
 	MaxKeys           int
 	ContinuationToken string
+
+	// Optional: only return objects modified after / before these times. The providers
+	// cannot filter server-side, so pages may hold fewer objects than MaxKeys.
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	// IncludeDeleted also lists soft-deleted objects that can still be undeleted
+	// (Azure soft delete; S3 has no soft delete and ignores it)
+	IncludeDeleted bool
+}
+
+// MatchesModified reports whether an object's last modified time lies within
+// ModifiedAfter and ModifiedBefore
+func (o ListObjectsOptions) MatchesModified(t time.Time) bool {
+	if !o.ModifiedAfter.IsZero() && !t.After(o.ModifiedAfter) {
+		return false
+	}
+
+	if !o.ModifiedBefore.IsZero() && !t.Before(o.ModifiedBefore) {
+		return false
+	}
+
+	return true
 }
 
 // ListObjectsResult contains the result of listing objects
@@ -65,6 +136,46 @@ type ListObjectsResult struct {
 	Prefixes          []string `json:"prefixes"`
 	IsTruncated       bool     `json:"isTruncated"`
 	ContinuationToken *string  `json:"continuationToken,omitempty"`
+
+	// ObjectCount and PrefixCount are the number of objects and prefixes in this page
+	ObjectCount int `json:"objectCount"`
+	PrefixCount int `json:"prefixCount"`
+
+	// HasMore is set when the listing stopped at MaxKeys (or the provider's page limit)
+	// and ContinuationToken fetches the next page. Filtered pages may hold fewer entries.
+	HasMore bool `json:"hasMore"`
+
+	// PrefixSizes contains aggregated sizes per prefix (only when requested)
+	PrefixSizes map[string]PrefixSize `json:"prefixSizes,omitempty"`
+}
+
+// NewListObjectsResult builds a page of a listing; next is the provider's
+// token of the following page, nil or empty if the listing is complete
+func NewListObjectsResult(objects []Object, prefixes []string, next *string) *ListObjectsResult {
+	result := &ListObjectsResult{
+		Objects:  objects,
+		Prefixes: prefixes,
+
+		ObjectCount: len(objects),
+		PrefixCount: len(prefixes),
+	}
+
+	if next != nil && *next != "" {
+		result.IsTruncated = true
+		result.HasMore = true
+		result.ContinuationToken = next
+	}
+
+	return result
+}
+
+// PrefixSize contains the aggregated object count and size below a prefix
+type PrefixSize struct {
+	Objects int64 `json:"objects"`
+	Size    int64 `json:"size"`
+
+	// Approximate is set when the scan stopped at the object limit
+	Approximate bool `json:"approximate,omitempty"`
 }
 
 // ObjectDetails contains detailed metadata for an object
@@ -76,15 +187,228 @@ type ObjectDetails struct {
 	ContentType  *string           `json:"contentType,omitempty"`
 	Metadata     map[string]string `json:"metadata,omitempty"`
 	StorageClass *string           `json:"storageClass,omitempty"`
+	Encrypted    *bool             `json:"encrypted,omitempty"`
+	// HTTP headers served with the object
+	CacheControl       *string `json:"cacheControl,omitempty"`
+	ContentDisposition *string `json:"contentDisposition,omitempty"`
+	// Checksums stored by the provider (hex encoded), if known
+	ContentMD5     *string `json:"contentMd5,omitempty"`
+	ChecksumSHA256 *string `json:"checksumSha256,omitempty"`
+	// Lifecycle expiry: when the object is scheduled to be deleted (RFC 3339) and by which rule
+	Expiry     *string `json:"expiry,omitempty"`
+	ExpiryRule *string `json:"expiryRule,omitempty"`
+	// Retention: immutability policy (S3 object lock) and legal hold
+	ImmutabilityPolicy *ImmutabilityPolicy `json:"immutabilityPolicy,omitempty"`
+	LegalHold          *bool               `json:"legalHold,omitempty"`
 	// S3 specific
-	VersionID *string `json:"versionId,omitempty"`
+	VersionID            *string `json:"versionId,omitempty"`
+	ServerSideEncryption *string `json:"serverSideEncryption,omitempty"`
+	SSEKMSKeyID          *string `json:"sseKmsKeyId,omitempty"`
 	// Azure specific
 	AccessTier *string `json:"accessTier,omitempty"`
 	BlobType   *string `json:"blobType,omitempty"`
 }
 
+// ImmutabilityPolicy describes a retention period during which an object cannot be changed or deleted
+type ImmutabilityPolicy struct {
+	Mode        string `json:"mode"` // e.g. "GOVERNANCE"/"COMPLIANCE" on S3, "Unlocked"/"Locked" on Azure
+	RetainUntil string `json:"retainUntil"`
+}
+
+// ConfigField describes a connection config field for building forms
+type ConfigField struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Type     string `json:"type"` // "string", "password", "number", "boolean", "list", "map", "object"
+	Required bool   `json:"required"`
+
+	Fields []ConfigField `json:"fields,omitempty"` // fields of an "object"
+}
+
+// UploadOptions contains optional settings for uploading an object
+type UploadOptions struct {
+	ContentType string
+
+	// Metadata contains user-defined metadata (x-amz-meta-* on S3, blob metadata on Azure)
+	Metadata map[string]string
+
+	// IfMatch only overwrites the object if its current ETag matches; the
+	// upload fails with ErrPreconditionFailed otherwise
+	IfMatch string
+
+	// ACL is a canned ACL such as "private" or "public-read" (S3 only; Azure
+	// controls public access per container and accepts "private" alone)
+	ACL string
+
+	// S3 specific: server-side encryption ("AES256" or "aws:kms") and the KMS key to use
+	ServerSideEncryption string
+	SSEKMSKeyID          string
+}
+
+// ObjectUpdate contains changes to an object's metadata and HTTP headers;
+// nil fields are left unchanged and empty headers are removed
+type ObjectUpdate struct {
+	// Metadata replaces all user metadata of the object
+	Metadata map[string]string
+
+	CacheControl       *string
+	ContentDisposition *string
+}
+
+// GetObjectOptions contains optional settings for downloading an object
+type GetObjectOptions struct {
+	// Range limits the download to a byte range of the object (nil = whole object)
+	Range *ByteRange
+}
+
+// ByteRange is a range of Length bytes starting at Offset
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+// PresignOptions contains options for generating a presigned download URL
+type PresignOptions struct {
+	ExpiresIn int // seconds, defaults to one hour
+
+	// Optional overrides of the Content-Type and Content-Disposition response headers
+	ResponseContentType        string
+	ResponseContentDisposition string
+}
+
+// ObjectContent is an object opened for download
+type ObjectContent struct {
+	Body            io.ReadCloser
+	Size            int64
+	ContentType     string
+	ContentEncoding string
+	LastModified    time.Time
+}
+
 // GetObjectName extracts the display name from an object key
 func GetObjectName(key string) string {
 	key = strings.TrimSuffix(key, "/")
 	return filepath.Base(key)
 }
+
+// ComputePrefixSizes aggregates object counts and sizes for each prefix by
+// listing everything below it. This costs additional list calls per prefix,
+// so each scan stops after maxObjects objects and is flagged as approximate.
+func ComputePrefixSizes(ctx context.Context, p Provider, container string, prefixes []string, maxObjects int) (map[string]PrefixSize, error) {
+	result := make(map[string]PrefixSize, len(prefixes))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+
+	// bound the number of concurrent listings
+	sem := make(chan struct{}, 4)
+
+	for _, prefix := range prefixes {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			size, err := computePrefixSize(ctx, p, container, prefix, maxObjects)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			result[prefix] = size
+		}()
+	}
+
+	wg.Wait()
+
+	return result, firstErr
+}
+
+// ListPrefixes returns all common prefixes ("folders") directly below prefix,
+// paging through the listing and discarding the objects
+func ListPrefixes(ctx context.Context, p Provider, container, prefix, delimiter string) ([]string, error) {
+	prefixes := make([]string, 0)
+
+	opts := ListObjectsOptions{
+		Prefix:    prefix,
+		Delimiter: delimiter,
+	}
+
+	for {
+		page, err := p.ListObjects(ctx, container, opts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, page.Prefixes...)
+
+		if !page.HasMore {
+			return prefixes, nil
+		}
+
+		opts.ContinuationToken = *page.ContinuationToken
+	}
+}
+
+func computePrefixSize(ctx context.Context, p Provider, container, prefix string, maxObjects int) (PrefixSize, error) {
+	var size PrefixSize
+
+	opts := ListObjectsOptions{
+		Prefix: prefix,
+	}
+
+	for {
+		page, err := p.ListObjects(ctx, container, opts)
+
+		if err != nil {
+			return size, err
+		}
+
+		for _, obj := range page.Objects {
+			size.Objects++
+			size.Size += obj.Size
+		}
+
+		if !page.HasMore {
+			return size, nil
+		}
+
+		if maxObjects > 0 && size.Objects >= int64(maxObjects) {
+			size.Approximate = true
+			return size, nil
+		}
+
+		opts.ContinuationToken = *page.ContinuationToken
+	}
+}
+
+// MultipartUpload identifies an object upload in progress that is sent in parts
+type MultipartUpload struct {
+	Container string
+	Key       string
+
+	// ID is the upload ID on S3 and the prefix of the block IDs on Azure
+	ID string
+
+	// Options are applied when the upload is created (S3) or completed (Azure)
+	Options UploadOptions
+}
+
+// CompletedPart is an uploaded part of a multipart upload
+type CompletedPart struct {
+	PartNumber int
+
+	// ETag as returned by UploadPart (the block ID on Azure)
+	ETag string
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TrashPrefix is the prefix deleted objects are moved below when trash is enabled
+const TrashPrefix = ".trash/"
+
+// Metadata keys recording where a trashed object came from. Underscores keep
+// them valid as Azure metadata names, which must be C# identifiers.
+const (
+	trashOriginalKey = "granite_original_key"
+	trashDeletedAt   = "granite_deleted_at"
+)
+
+// IsTrashKey reports whether a key refers to an object in the trash
+func IsTrashKey(key string) bool {
+	return strings.HasPrefix(key, TrashPrefix)
+}
+
+// TrashObjects moves objects to the trash by copying them below TrashPrefix,
+// recording the original key in their metadata, and deleting the originals.
+// It returns the keys of the objects in the trash.
+func TrashObjects(ctx context.Context, p Provider, container string, keys []string) ([]string, error) {
+	now := time.Now().UTC()
+
+	// Deleting the same key twice must not overwrite the earlier copy
+	stamp := now.Format("20060102T150405.000000000Z")
+
+	trashed := make([]string, 0, len(keys))
+	moved := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		details, err := p.GetObjectDetails(ctx, container, key)
+
+		if err != nil {
+			return trashed, err
+		}
+
+		metadata := maps.Clone(details.Metadata)
+
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+
+		// Metadata values are sent as headers, so the key is escaped to stay ASCII
+		metadata[trashOriginalKey] = url.PathEscape(key)
+		metadata[trashDeletedAt] = now.Format(time.RFC3339)
+
+		trashKey := TrashPrefix + stamp + "/" + key
+
+		if err := p.CopyObject(ctx, container, key, trashKey, metadata); err != nil {
+			return trashed, err
+		}
+
+		trashed = append(trashed, trashKey)
+		moved = append(moved, key)
+	}
+
+	if err := p.DeleteObjects(ctx, container, moved); err != nil {
+		return trashed, err
+	}
+
+	return trashed, nil
+}
+
+// RestoreObjects moves objects from the trash back to their original keys,
+// overwriting any object that has been created there since. It returns the
+// restored keys.
+func RestoreObjects(ctx context.Context, p Provider, container string, trashKeys []string) ([]string, error) {
+	restored := make([]string, 0, len(trashKeys))
+
+	for _, trashKey := range trashKeys {
+		if !IsTrashKey(trashKey) {
+			return restored, fmt.Errorf("%s is not in the trash", trashKey)
+		}
+
+		details, err := p.GetObjectDetails(ctx, container, trashKey)
+
+		if err != nil {
+			return restored, err
+		}
+
+		original, ok := metadataValue(details.Metadata, trashOriginalKey)
+
+		if !ok {
+			return restored, fmt.Errorf("%s has no original key recorded", trashKey)
+		}
+
+		key, err := url.PathUnescape(original)
+
+		if err != nil || key == "" {
+			return restored, errors.New("invalid original key for " + trashKey)
+		}
+
+		metadata := maps.Clone(details.Metadata)
+
+		maps.DeleteFunc(metadata, func(name, _ string) bool {
+			return strings.EqualFold(name, trashOriginalKey) || strings.EqualFold(name, trashDeletedAt)
+		})
+
+		if err := p.CopyObject(ctx, container, trashKey, key, metadata); err != nil {
+			return restored, err
+		}
+
+		if err := p.DeleteObject(ctx, container, trashKey); err != nil {
+			return restored, err
+		}
+
+		restored = append(restored, key)
+	}
+
+	return restored, nil
+}
+
+// EmptyTrash permanently deletes all objects in the trash of a container
+// and returns the number of deleted objects
+func EmptyTrash(ctx context.Context, p Provider, container string) (int, error) {
+	deleted := 0
+
+	opts := ListObjectsOptions{
+		Prefix: TrashPrefix,
+	}
+
+	for {
+		page, err := p.ListObjects(ctx, container, opts)
+
+		if err != nil {
+			return deleted, err
+		}
+
+		keys := make([]string, 0, len(page.Objects))
+
+		for _, obj := range page.Objects {
+			keys = append(keys, obj.Key)
+		}
+
+		if err := p.DeleteObjects(ctx, container, keys); err != nil {
+			return deleted, err
+		}
+
+		deleted += len(keys)
+
+		if !page.HasMore {
+			return deleted, nil
+		}
+
+		opts.ContinuationToken = *page.ContinuationToken
+	}
+}
+
+// metadataValue looks up a metadata entry by name. Providers may return names
+// in another case than they were stored in, so the lookup ignores case.
+func metadataValue(metadata map[string]string, name string) (string, bool) {
+	for k, v := range metadata {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,212 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Config contains the SSH connection used to reach a host behind a bastion
+type Config struct {
+	Host     string `json:"host"` // host or host:port of the SSH server (port defaults to 22)
+	Username string `json:"username"`
+
+	// Authentication: a password and/or a PEM encoded private key
+	Password   string `json:"password,omitempty"`
+	PrivateKey string `json:"privateKey,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"` // Optional: passphrase of an encrypted private key
+
+	// Optional: expected host key in authorized_keys format (e.g. "ssh-ed25519 AAAA...").
+	// Without it any host key is accepted.
+	HostKey string `json:"hostKey,omitempty"`
+}
+
+// Tunnel forwards connections accepted on a local address through SSH to a remote address
+type Tunnel struct {
+	client   *ssh.Client
+	listener net.Listener
+
+	target string
+
+	wg sync.WaitGroup
+}
+
+// New connects to the SSH server and starts forwarding connections made to
+// Addr() on the loopback interface to target (host:port, resolved by the SSH server)
+func New(ctx context.Context, cfg Config, target string) (*Tunnel, error) {
+	clientConfig, err := cfg.clientConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	addr := cfg.Host
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh server: %w", err)
+	}
+
+	// the handshake is bound to the context through a deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to ssh server: %w", err)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	t := &Tunnel{
+		client:   client,
+		listener: listener,
+
+		target: target,
+	}
+
+	t.wg.Add(1)
+	go t.serve()
+
+	return t, nil
+}
+
+// Addr returns the local address (host:port) that forwards to the target
+func (t *Tunnel) Addr() string {
+	return t.listener.Addr().String()
+}
+
+// Close stops accepting connections and closes the SSH connection,
+// which also terminates all forwarded connections
+func (t *Tunnel) Close() error {
+	err := t.listener.Close()
+
+	t.client.Close()
+	t.wg.Wait()
+
+	return err
+}
+
+func (t *Tunnel) serve() {
+	defer t.wg.Done()
+
+	for {
+		local, err := t.listener.Accept()
+
+		if err != nil {
+			return
+		}
+
+		t.wg.Add(1)
+
+		go func() {
+			defer t.wg.Done()
+			defer local.Close()
+
+			remote, err := t.client.Dial("tcp", t.target)
+
+			if err != nil {
+				slog.Error("failed to dial through ssh tunnel", "target", t.target, "error", err)
+				return
+			}
+
+			defer remote.Close()
+
+			done := make(chan struct{}, 2)
+
+			go func() {
+				io.Copy(remote, local)
+				done <- struct{}{}
+			}()
+
+			go func() {
+				io.Copy(local, remote)
+				done <- struct{}{}
+			}()
+
+			// either side closing ends the forwarded connection
+			<-done
+		}()
+	}
+}
+
+func (c Config) clientConfig() (*ssh.ClientConfig, error) {
+	if c.Host == "" || c.Username == "" {
+		return nil, errors.New("ssh host and username are required")
+	}
+
+	var auth []ssh.AuthMethod
+
+	if c.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+
+		if c.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(c.PrivateKey), []byte(c.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(c.PrivateKey))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh private key: %w", err)
+		}
+
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+
+	if c.Password != "" {
+		auth = append(auth, ssh.Password(c.Password))
+	}
+
+	if len(auth) == 0 {
+		return nil, errors.New("ssh password or private key is required")
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+
+	if c.HostKey != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(c.HostKey)))
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh host key: %w", err)
+		}
+
+		hostKeyCallback = ssh.FixedHostKey(key)
+	}
+
+	return &ssh.ClientConfig{
+		User: c.Username,
+		Auth: auth,
+
+		HostKeyCallback: hostKeyCallback,
+
+		Timeout: 30 * time.Second,
+	}, nil
+}
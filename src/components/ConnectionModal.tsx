@@ -75,8 +75,8 @@ function getInitialValues(connection?: Connection | null): FormValues {
       name: connection.name,
       storageProvider: 's3',
       s3Region: connection.amazonS3.region,
-      s3AccessKeyId: connection.amazonS3.accessKeyId,
-      s3SecretAccessKey: connection.amazonS3.secretAccessKey,
+      s3AccessKeyId: connection.amazonS3.accessKeyId ?? '',
+      s3SecretAccessKey: connection.amazonS3.secretAccessKey ?? '',
       s3Endpoint: connection.amazonS3.endpoint ?? '',
     };
   }
@@ -131,8 +131,7 @@ export function ConnectionModal({ connection, onSave, onClose }: ConnectionModal
         name: value.name,
         amazonS3: {
           region: value.s3Region || 'us-east-1',
-          accessKeyId: value.s3AccessKeyId,
-          secretAccessKey: value.s3SecretAccessKey,
+          ...(value.s3AccessKeyId && { accessKeyId: value.s3AccessKeyId, secretAccessKey: value.s3SecretAccessKey }),
           ...(value.s3Endpoint && { endpoint: value.s3Endpoint }),
         },
       };
@@ -566,7 +565,7 @@ export function ConnectionModal({ connection, onSave, onClose }: ConnectionModal
               const formValid = (() => {
                 if (!values.name) return false;
                 if (values.category === 'database') return !!values.dsn;
-                if (values.storageProvider === 's3') return !!values.s3AccessKeyId && !!values.s3SecretAccessKey;
+                if (values.storageProvider === 's3') return !!values.s3AccessKeyId === !!values.s3SecretAccessKey;
                 return !!values.azureAccountName && (!!values.azureAccountKey || !!values.azureConnectionString);
               })();
 
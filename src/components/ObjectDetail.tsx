@@ -49,7 +49,8 @@ export function ObjectDetail({ connection, container, objectKey, onClose }: Obje
   const handleDownload = async () => {
     setDownloadError(null);
     try {
-      const url = await getPresignedUrl(connection.id, container, objectKey);
+      // The download attribute is ignored for cross-origin links, so the name is set in the URL
+      const url = await getPresignedUrl(connection.id, container, objectKey, 3600, getDisplayName(objectKey));
       // Create a temporary anchor element to trigger download
       const link = document.createElement('a');
       link.href = url;
@@ -137,6 +138,18 @@ export function ObjectDetail({ connection, container, objectKey, onClose }: Obje
             )}
           </DetailSection>
 
+          {/* HTTP Headers */}
+          {(details.cacheControl || details.contentDisposition) && (
+            <DetailSection title="Headers">
+              {details.cacheControl && (
+                <DetailRow label="Cache-Control" value={details.cacheControl} mono />
+              )}
+              {details.contentDisposition && (
+                <DetailRow label="Content-Disposition" value={details.contentDisposition} mono />
+              )}
+            </DetailSection>
+          )}
+
           {/* Storage Info */}
           {(details.storageClass || details.accessTier || details.blobType) && (
             <DetailSection title="Storage">
@@ -155,6 +168,27 @@ export function ObjectDetail({ connection, container, objectKey, onClose }: Obje
             </DetailSection>
           )}
 
+          {/* Lifecycle & Retention */}
+          {(details.expiry || details.immutabilityPolicy || details.legalHold) && (
+            <DetailSection title="Retention">
+              {details.expiry && (
+                <DetailRow label="Expires" value={formatDate(details.expiry)} />
+              )}
+              {details.expiryRule && (
+                <DetailRow label="Expiry Rule" value={details.expiryRule} />
+              )}
+              {details.immutabilityPolicy && (
+                <DetailRow
+                  label="Retain Until"
+                  value={`${formatDate(details.immutabilityPolicy.retainUntil)} (${details.immutabilityPolicy.mode})`}
+                />
+              )}
+              {details.legalHold && (
+                <DetailRow label="Legal Hold" value="On" />
+              )}
+            </DetailSection>
+          )}
+
           {/* Custom Metadata */}
           {details.metadata && Object.keys(details.metadata).length > 0 && (
             <DetailSection title="Metadata">
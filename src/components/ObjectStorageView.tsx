@@ -265,7 +265,7 @@ export function ObjectStorageView({ connection, container, path, onNavigate }: O
         {/* Footer */}
         <div className="px-4 py-2 border-t border-neutral-200 dark:border-white/8 text-xs text-neutral-400 dark:text-neutral-500">
           {items.length} {items.length === 1 ? 'item' : 'items'}
-          {objects?.isTruncated && ' (truncated)'}
+          {objects?.hasMore && ' (truncated)'}
         </div>
       </div>
 
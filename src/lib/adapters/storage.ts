@@ -2,6 +2,7 @@ import type {
   StorageContainer,
   StorageObject,
   StorageObjectDetails,
+  StorageObjectRange,
 } from '../../types';
 
 // ============================================================================
@@ -13,6 +14,9 @@ export interface ListObjectsOptions {
   delimiter?: string;
   maxKeys?: number;
   continuationToken?: string;
+  modifiedAfter?: string; // RFC 3339
+  modifiedBefore?: string; // RFC 3339
+  includeDeleted?: boolean; // Azure soft-deleted blobs
 }
 
 export interface ListObjectsResult {
@@ -20,6 +24,9 @@ export interface ListObjectsResult {
   prefixes: string[]; // Common prefixes (folders)
   isTruncated: boolean;
   continuationToken?: string;
+  objectCount: number; // Objects in this page
+  prefixCount: number; // Prefixes in this page
+  hasMore: boolean; // More results can be fetched with the continuation token
 }
 
 // List all containers
@@ -67,6 +74,9 @@ export async function listObjects(
       delimiter: options.delimiter ?? '/',
       maxKeys: options.maxKeys || 1000,
       continuationToken: options.continuationToken,
+      modifiedAfter: options.modifiedAfter,
+      modifiedBefore: options.modifiedBefore,
+      includeDeleted: options.includeDeleted,
     }),
   });
 
@@ -98,17 +108,66 @@ export async function getObjectDetails(
   return response.json();
 }
 
+// Read a byte range of an object (at most 1 MiB, 64 KiB by default)
+export async function readObjectRange(
+  connectionId: string,
+  container: string,
+  key: string,
+  offset: number,
+  length?: number
+): Promise<StorageObjectRange> {
+  const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/object/range`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ container, key, offset, length }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.message || 'Failed to read object range');
+  }
+
+  const result = await response.json();
+
+  return {
+    ...result,
+    data: Uint8Array.from(atob(result.data), (c) => c.charCodeAt(0)),
+  };
+}
+
+// Change the metadata and HTTP headers of an object (omitted fields stay unchanged)
+export async function updateObject(
+  connectionId: string,
+  container: string,
+  key: string,
+  update: { metadata?: Record<string, string>; cacheControl?: string; contentDisposition?: string }
+): Promise<StorageObjectDetails> {
+  const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/object/update`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ container, key, ...update }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.message || 'Failed to update object');
+  }
+
+  return response.json();
+}
+
 // Generate a presigned URL for downloading an object
 export async function getPresignedUrl(
   connectionId: string,
   container: string,
   key: string,
-  expiresIn: number = 3600
+  expiresIn: number = 3600,
+  downloadFilename?: string
 ): Promise<string> {
   const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/object/presign`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ container, key, expiresIn }),
+    body: JSON.stringify({ container, key, expiresIn, downloadFilename }),
   });
 
   if (!response.ok) {
@@ -143,6 +202,134 @@ export async function uploadObject(
   }
 }
 
+export interface MultipartUpload {
+  uploadId: string;
+  container: string;
+  key: string;
+  maxParts: number;
+  maxPartSize: number;
+  parts: { partNumber: number; size: number; etag: string }[];
+}
+
+// Start a multipart upload of an object
+export async function createMultipartUpload(
+  connectionId: string,
+  container: string,
+  key: string,
+  contentType?: string
+): Promise<MultipartUpload> {
+  const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/multipart`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ container, key, contentType }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.message || 'Failed to start upload');
+  }
+
+  return response.json();
+}
+
+// Get a multipart upload and the parts uploaded so far
+export async function getMultipartUpload(connectionId: string, uploadId: string): Promise<MultipartUpload> {
+  const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/multipart/${encodeURIComponent(uploadId)}`);
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.message || 'Failed to get upload');
+  }
+
+  return response.json();
+}
+
+// Upload a part (numbered from 1) of a multipart upload
+export async function uploadPart(
+  connectionId: string,
+  uploadId: string,
+  partNumber: number,
+  data: Blob
+): Promise<void> {
+  const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/multipart/${encodeURIComponent(uploadId)}/${partNumber}`, {
+    method: 'PUT',
+    body: data,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.message || `Failed to upload part ${partNumber}`);
+  }
+}
+
+// Assemble the object from the uploaded parts
+export async function completeMultipartUpload(connectionId: string, uploadId: string): Promise<void> {
+  const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/multipart/${encodeURIComponent(uploadId)}/complete`, {
+    method: 'POST',
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.message || 'Failed to complete upload');
+  }
+}
+
+// Abort a multipart upload and discard its parts
+export async function abortMultipartUpload(connectionId: string, uploadId: string): Promise<void> {
+  const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/multipart/${encodeURIComponent(uploadId)}`, {
+    method: 'DELETE',
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.message || 'Failed to abort upload');
+  }
+}
+
+// Upload a large file in parts, several at a time. Passing the uploadId of an
+// interrupted upload resumes it, skipping the parts that were already uploaded.
+export async function uploadObjectInParts(
+  connectionId: string,
+  container: string,
+  key: string,
+  file: File,
+  options: { uploadId?: string; partSize?: number; concurrency?: number } = {}
+): Promise<void> {
+  // S3 requires parts of at least 5 MiB, except for the last one
+  const partSize = options.partSize ?? 8 * 1024 * 1024;
+  const concurrency = options.concurrency ?? 4;
+
+  const upload = options.uploadId
+    ? await getMultipartUpload(connectionId, options.uploadId)
+    : await createMultipartUpload(connectionId, container, key, file.type || undefined);
+
+  const done = new Set(upload.parts.map(p => p.partNumber));
+  const count = Math.max(1, Math.ceil(file.size / partSize));
+
+  if (partSize > upload.maxPartSize) {
+    throw new Error(`Part size exceeds ${upload.maxPartSize} bytes`);
+  }
+
+  if (count > upload.maxParts) {
+    throw new Error(`File needs more than ${upload.maxParts} parts, use a larger part size`);
+  }
+
+  const pending: number[] = [];
+
+  for (let n = 1; n <= count; n++) {
+    if (!done.has(n)) pending.push(n);
+  }
+
+  const worker = async () => {
+    for (let n = pending.shift(); n !== undefined; n = pending.shift()) {
+      await uploadPart(connectionId, upload.uploadId, n, file.slice((n - 1) * partSize, n * partSize));
+    }
+  };
+
+  await Promise.all(Array.from({ length: Math.min(concurrency, pending.length) }, worker));
+  await completeMultipartUpload(connectionId, upload.uploadId);
+}
+
 // Delete one or more objects from storage
 export async function deleteObjects(
   connectionId: string,
@@ -161,6 +348,24 @@ export async function deleteObjects(
   }
 }
 
+// Restore a soft-deleted object (Azure soft delete)
+export async function undeleteObject(
+  connectionId: string,
+  container: string,
+  key: string
+): Promise<void> {
+  const response = await fetch(`/storage/${encodeURIComponent(connectionId)}/object/undelete`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ container, key }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.message || 'Failed to undelete object');
+  }
+}
+
 // Delete all objects with a given prefix (for folder deletion)
 export async function deletePrefix(
   connectionId: string,
@@ -181,7 +386,7 @@ export async function deletePrefix(
 
     await deleteObjects(connectionId, container, result.objects.map(obj => obj.key));
 
-    if (!result.isTruncated) {
+    if (!result.hasMore) {
       break;
     }
   }
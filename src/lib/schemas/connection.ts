@@ -13,10 +13,13 @@ export const s3FormSchema = z.object({
   storageProvider: z.literal('s3'),
   name: z.string().min(1, 'Connection name is required'),
   s3Region: z.string(),
-  s3AccessKeyId: z.string().min(1, 'Access Key ID is required'),
-  s3SecretAccessKey: z.string().min(1, 'Secret Access Key is required'),
+  s3AccessKeyId: z.string(),
+  s3SecretAccessKey: z.string(),
   s3Endpoint: z.string().optional(),
-});
+}).refine(
+  (data) => !!data.s3AccessKeyId === !!data.s3SecretAccessKey,
+  { message: 'Access Key ID and Secret Access Key must be set together', path: ['s3SecretAccessKey'] }
+);
 
 // Azure storage form schema
 export const azureFormSchema = z.object({
@@ -18,8 +18,10 @@ export interface SQLConfig {
 export interface S3Config {
   endpoint?: string; // Optional custom endpoint (for MinIO, etc.)
   region: string;
-  accessKeyId: string;
-  secretAccessKey: string;
+  accessKeyId?: string; // Optional: the default AWS credential chain is used without keys
+  secretAccessKey?: string;
+  signingAccessKeyId?: string; // Optional key pair used only for presigned URLs
+  signingSecretAccessKey?: string;
 }
 
 // Azure Blob storage configuration
@@ -80,6 +82,7 @@ export interface StorageObject {
   etag?: string;
   contentType?: string;
   isFolder: boolean;
+  deleted?: boolean; // soft-deleted (Azure), can be undeleted
 }
 
 // Storage container
@@ -98,6 +101,17 @@ export interface StorageObjectDetails {
   contentType?: string;
   metadata?: Record<string, string>;
   storageClass?: string;
+  // HTTP headers served with the object
+  cacheControl?: string;
+  contentDisposition?: string;
+  // Lifecycle and retention
+  expiry?: string;
+  expiryRule?: string;
+  immutabilityPolicy?: {
+    mode: string;
+    retainUntil: string;
+  };
+  legalHold?: boolean;
   // S3 specific
   versionId?: string;
   // Azure specific
@@ -105,6 +119,15 @@ export interface StorageObjectDetails {
   blobType?: string;
 }
 
+// Byte range of an object (e.g. for the hex viewer)
+export interface StorageObjectRange {
+  offset: number;
+  length: number; // bytes returned, less than requested at the end of the object
+  size: number; // total size of the object
+  data: Uint8Array;
+  eof: boolean;
+}
+
 // ============================================================================
 // SQL Types
 // ============================================================================